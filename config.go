@@ -0,0 +1,586 @@
+package main
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/period"
+	"callCenterReportMaker/renderer"
+	"callCenterReportMaker/repository/database"
+	"callCenterReportMaker/scheduler"
+	"callCenterReportMaker/service"
+	"callCenterReportMaker/templates"
+	"errors"
+	"fmt"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	configPath      = "config.yaml"
+	configEnvPrefix = "CALLCENTER"
+)
+
+// configSecrets may be set from the environment instead of config.yaml,
+// database.password is read from CALLCENTER_DATABASE_PASSWORD
+var configSecrets = []string{"database.host", "database.port", "database.database", "database.user", "database.password",
+	"telegram.token", "telegram.chatId"}
+
+// requiredKeys have no sensible default, they must be set in config.yaml or in the environment
+var requiredKeys = []string{"salary.orderFee", "salary.operators", "database.host", "database.user", "database.password",
+	"telegram.token", "telegram.chatId"}
+
+// configKeys are all the keys config.yaml may have, the keys ending with a dot hold maps with arbitrary keys
+var configKeys = []string{
+	"timezone",
+	"salary.orderFee", "salary.personalConversionGrade", "salary.operators", "salary.motivationMap.", "salary.commissionTiers.",
+	"database.host", "database.port", "database.database", "database.user", "database.password",
+	"telegram.token", "telegram.chatId", "telegram.imageChats", "telegram.operatorChats",
+	"scheduler.timezone", "scheduler.jobs", "scheduler.statusPath", "report.weekdayReportTime", "report.weekendReportTime", "report.formats",
+	"payslip.fontPath", "images.fontPath", "templates.dir",
+	"uniqueness.policy", "uniqueness.days",
+	"cities", "citiesAndLinesRegexpMap.",
+	"linkage.windowDays",
+	"anomaly.baselineDays", "anomaly.dropRatio", "anomaly.minBaseline",
+	"holidays.file", "holidays.overrides", "holidays.feeMultiplier", "holidays.shortDayFeeMultiplier",
+	"shifts.path",
+	"mango.talkTimeColumn", "mango.waitTimeColumn", "mango.ringTimeColumn",
+	"orders.statuses.", "orders.paidPath", "orders.pendingPath", "orders.amountColumn",
+	"talkTime.shortCallSeconds", "talkTime.bonusMaxShortCallsShare", "talkTime.bonusMinAverageHandleSeconds",
+	"marketing.sources.", "marketing.spend",
+	"expenses",
+	"periods.statistics.type", "periods.statistics.weekStart", "periods.statistics.anchor",
+	"periods.payroll.type", "periods.payroll.weekStart", "periods.payroll.anchor", "periods.named",
+}
+
+// config is everything read from config.yaml
+type config struct {
+	cityLines               service.CityLines
+	operators               []string
+	motivationMap           map[float64]float64
+	orderFee                float64
+	personalConversionGrade float64
+	dbHost                  string
+	dbPort                  string
+	dbName                  string
+	dbUser                  string
+	dbPassword              string
+	telegramToken           string
+	telegramChatId          int64
+	schedulerJobs           []scheduler.Job
+	schedulerLocation       *time.Location
+	schedulerStatusPath     string
+	businessLocation        *time.Location
+	uniquenessPolicy        service.UniquenessPolicy
+	sourceLines             []service.SourceLine
+	adSpends                []entity.AdSpend
+	linkageWindowDays       int
+	anomalySettings         service.AnomalySettings
+	reportFormats           []string
+	payslipFontPath         string
+	operatorChats           map[string]int64
+	imagesFontPath          string
+	imageChats              []int64
+	reportTemplates         templates.Templates
+	recurringExpenses       []service.RecurringExpense
+	calendar                period.Calendar
+	workCalendar            period.WorkCalendar
+	holidayFees             service.HolidayFees
+	shiftsPath              string
+	durationColumns         database.DurationColumns
+	talkTimeSettings        service.TalkTimeSettings
+	orderStatuses           entity.OrderStatuses
+	paidOrdersPath          string
+	pendingOrdersPath       string
+	orderColumns            database.OrderColumns
+	commissionTiers         service.CommissionTiers
+}
+
+// configReader reads the typed values and collects every problem under its YAML key
+type configReader struct {
+	errs []error
+}
+
+func (r *configReader) fail(key string, err error) {
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: %w", key, err))
+	}
+}
+
+func (r *configReader) getString(key string) string {
+	value, err := cast.ToStringE(viper.Get(key))
+	r.fail(key, err)
+	return value
+}
+
+func (r *configReader) getStrings(key string) []string {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	value, err := cast.ToStringSliceE(viper.Get(key))
+	r.fail(key, err)
+	return value
+}
+
+func (r *configReader) getInt(key string) int {
+	value, err := cast.ToIntE(viper.Get(key))
+	r.fail(key, err)
+	return value
+}
+
+func (r *configReader) getInt64(key string) int64 {
+	value, err := cast.ToInt64E(viper.Get(key))
+	r.fail(key, err)
+	return value
+}
+
+func (r *configReader) getFloat(key string) float64 {
+	value, err := cast.ToFloat64E(viper.Get(key))
+	r.fail(key, err)
+	return value
+}
+
+// getFloatMap reads a map of numbers to numbers, the keys are numbers written as YAML keys
+func (r *configReader) getFloatMap(key string) map[float64]float64 {
+	values := make(map[float64]float64)
+	for fromStr, toStr := range viper.GetStringMapString(key) {
+		from, err := strconv.ParseFloat(fromStr, 64)
+		if err != nil {
+			r.fail(key+"."+fromStr, err)
+			continue
+		}
+		to, err := strconv.ParseFloat(toStr, 64)
+		if err != nil {
+			r.fail(key+"."+fromStr, err)
+			continue
+		}
+		values[from] = to
+	}
+	return values
+}
+
+func (r *configReader) getLocation(key string) *time.Location {
+	location, err := time.LoadLocation(r.getString(key))
+	if err != nil {
+		r.fail(key, err)
+		//the following dates are still checked
+		return time.Local
+	}
+	return location
+}
+
+func (r *configReader) getDate(key, value string, location *time.Location) time.Time {
+	date, err := time.ParseInLocation(configDateLayout, value, location)
+	r.fail(key, err)
+	return date
+}
+
+func (r *configReader) unmarshal(key string, target interface{}) {
+	r.fail(key, viper.UnmarshalKey(key, target))
+}
+
+// checkKeys reports the keys config.yaml has but nothing reads, usually misspelled ones
+func (r *configReader) checkKeys() {
+	for _, key := range viper.AllKeys() {
+		known := false
+		for _, configKey := range configKeys {
+			configKey = strings.ToLower(configKey)
+			if key == configKey || key == strings.TrimSuffix(configKey, ".") ||
+				strings.HasSuffix(configKey, ".") && strings.HasPrefix(key, configKey) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			r.fail(key, errors.New("unknown key"))
+		}
+	}
+}
+
+// checkRequired reports the required keys set neither in config.yaml nor in the environment
+func (r *configReader) checkRequired() {
+	for _, key := range requiredKeys {
+		if !viper.IsSet(key) {
+			r.fail(key, errors.New("required"))
+		}
+	}
+}
+
+// initConfig points viper at config.yaml and the secrets at their environment variables
+func initConfig() error {
+	viper.SetConfigFile(configPath)
+	viper.SetEnvPrefix(configEnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for _, key := range configSecrets {
+		if err := viper.BindEnv(key); err != nil {
+			return err
+		}
+	}
+	return viper.ReadInConfig()
+}
+
+// loadConfig reads and checks the whole config, all the problems are returned at once
+func loadConfig() (config, error) {
+	var r configReader
+	r.checkKeys()
+	r.checkRequired()
+	cfg := config{
+		motivationMap: make(map[float64]float64),
+		adSpends:      make([]entity.AdSpend, 0),
+		operatorChats: make(map[string]int64),
+		orderStatuses: make(entity.OrderStatuses),
+	}
+
+	cfg.orderFee = r.getFloat("salary.orderFee")
+	cfg.personalConversionGrade = r.getFloat("salary.personalConversionGrade")
+	cfg.operators = r.getStrings("salary.operators")
+	cfg.dbHost = r.getString("database.host")
+	cfg.dbPort = r.getString("database.port")
+	cfg.dbName = r.getString("database.database")
+	cfg.dbUser = r.getString("database.user")
+	cfg.dbPassword = r.getString("database.password")
+	cfg.telegramToken = r.getString("telegram.token")
+	cfg.telegramChatId = r.getInt64("telegram.chatId")
+
+	//dates in the database, config, commands and reports are wall clock time of the business time zone
+	viper.SetDefault("timezone", "Local")
+	cfg.businessLocation = r.getLocation("timezone")
+	viper.SetDefault("scheduler.timezone", viper.GetString("timezone"))
+	cfg.schedulerLocation = r.getLocation("scheduler.timezone")
+	//without configured jobs the statistics are sent at the old report times
+	if !viper.IsSet("scheduler.jobs") {
+		viper.SetDefault("report.weekendReportTime", viper.GetString("report.weekdayReportTime"))
+		weekdayCron, err := clockToCron(r.getString("report.weekdayReportTime"))
+		r.fail("report.weekdayReportTime", err)
+		weekendCron, err := clockToCron(r.getString("report.weekendReportTime"))
+		r.fail("report.weekendReportTime", err)
+		viper.SetDefault("scheduler.jobs", []map[string]interface{}{
+			{"name": "statistics", "type": scheduler.DailyStats, "cron": weekdayCron + "1-5"},
+			{"name": "statisticsWeekend", "type": scheduler.DailyStats, "cron": weekendCron + "0,6"},
+			{"name": "anomalies", "type": scheduler.Anomalies, "cron": weekdayCron + "*"},
+			{"name": "payrollDraft", "type": scheduler.WeeklyDraft, "cron": weekdayCron + "1"},
+		})
+	}
+	r.unmarshal("scheduler.jobs", &cfg.schedulerJobs)
+	for i, job := range cfg.schedulerJobs {
+		r.fail(fmt.Sprintf("scheduler.jobs[%d]", i), job.Validate())
+	}
+	//the last runs are kept next to the payroll draft
+	viper.SetDefault("scheduler.statusPath", "data/jobs.json")
+	cfg.schedulerStatusPath = r.getString("scheduler.statusPath")
+
+	viper.SetDefault("payslip.fontPath", "data/DejaVuSans.ttf")
+	cfg.payslipFontPath = r.getString("payslip.fontPath")
+
+	viper.SetDefault("images.fontPath", cfg.payslipFontPath)
+	cfg.imagesFontPath = r.getString("images.fontPath")
+	r.unmarshal("telegram.imageChats", &cfg.imageChats)
+
+	var operatorChatsConfig []struct {
+		Name   string
+		ChatId int64
+	}
+	r.unmarshal("telegram.operatorChats", &operatorChatsConfig)
+	for _, operatorChat := range operatorChatsConfig {
+		cfg.operatorChats[operatorChat.Name] = operatorChat.ChatId
+	}
+
+	viper.SetDefault("templates.dir", "data/templates")
+	cfg.reportTemplates = templates.New(r.getString("templates.dir"))
+	if _, err := cfg.reportTemplates.Statistics(); err != nil {
+		r.fail("templates.dir", err)
+	}
+	if _, err := cfg.reportTemplates.XlsxLayout(); err != nil {
+		r.fail("templates.dir", err)
+	}
+
+	viper.SetDefault("report.formats", []string{renderer.Xlsx})
+	cfg.reportFormats = r.getStrings("report.formats")
+	for _, format := range cfg.reportFormats {
+		if _, err := renderer.New(format, cfg.reportTemplates); err != nil {
+			r.fail("report.formats", err)
+		}
+	}
+
+	viper.SetDefault("uniqueness.policy", service.FirstEver)
+	cfg.uniquenessPolicy = service.UniquenessPolicy{
+		Mode: r.getString("uniqueness.policy"),
+		Days: r.getInt("uniqueness.days"),
+	}
+	//the policy errors already name their keys
+	if err := cfg.uniquenessPolicy.Validate(); err != nil {
+		r.errs = append(r.errs, err)
+	}
+
+	cfg.cityLines = r.getCityLines()
+
+	viper.SetDefault("linkage.windowDays", 30)
+	cfg.linkageWindowDays = r.getInt("linkage.windowDays")
+
+	viper.SetDefault("anomaly.baselineDays", 14)
+	viper.SetDefault("anomaly.dropRatio", 0.5)
+	viper.SetDefault("anomaly.minBaseline", 3)
+	cfg.anomalySettings = service.AnomalySettings{
+		BaselineDays: r.getInt("anomaly.baselineDays"),
+		DropRatio:    r.getFloat("anomaly.dropRatio"),
+		MinBaseline:  r.getFloat("anomaly.minBaseline"),
+	}
+	if cfg.anomalySettings.BaselineDays <= 0 {
+		r.fail("anomaly.baselineDays", errors.New("must be positive"))
+	}
+
+	//the default calendar file is optional, an explicitly configured one must exist
+	viper.SetDefault("holidays.file", defaultHolidaysPath)
+	workCalendar, err := period.LoadWorkCalendar(r.getString("holidays.file"), cfg.businessLocation)
+	if err != nil && !(errors.Is(err, fs.ErrNotExist) && !viper.InConfig("holidays.file")) {
+		r.fail("holidays.file", err)
+	}
+	var holidayOverrides []struct {
+		Date string
+		Kind string
+		Name string
+	}
+	r.unmarshal("holidays.overrides", &holidayOverrides)
+	overrideDays := make([]period.Day, 0, len(holidayOverrides))
+	for _, override := range holidayOverrides {
+		day, err := period.ParseDay(override.Date, override.Kind, override.Name, cfg.businessLocation)
+		if err != nil {
+			r.fail("holidays.overrides", err)
+			continue
+		}
+		overrideDays = append(overrideDays, day)
+	}
+	cfg.workCalendar = workCalendar.With(overrideDays)
+
+	viper.SetDefault("holidays.feeMultiplier", 1)
+	viper.SetDefault("holidays.shortDayFeeMultiplier", 1)
+	cfg.holidayFees = service.HolidayFees{
+		HolidayMultiplier:  r.getFloat("holidays.feeMultiplier"),
+		ShortDayMultiplier: r.getFloat("holidays.shortDayFeeMultiplier"),
+	}
+	r.fail("holidays", cfg.holidayFees.Validate())
+
+	viper.SetDefault("shifts.path", "data/shifts.json")
+	cfg.shiftsPath = r.getString("shifts.path")
+
+	//the durations are read only from the mango_history columns named in config
+	cfg.durationColumns = database.DurationColumns{
+		TalkTime: r.getString("mango.talkTimeColumn"),
+		WaitTime: r.getString("mango.waitTimeColumn"),
+		RingTime: r.getString("mango.ringTimeColumn"),
+	}
+	r.fail("mango", cfg.durationColumns.Validate())
+	//status 5 was the only one left out before the mapping
+	viper.SetDefault("orders.statuses", map[string][]int{entity.OrderCancelled: {5}})
+	var statusGroups map[string][]int
+	r.unmarshal("orders.statuses", &statusGroups)
+	for group, statuses := range statusGroups {
+		switch group {
+		case entity.OrderCounted, entity.OrderPending, entity.OrderCancelled, entity.OrderReturned:
+		default:
+			r.fail("orders.statuses", fmt.Errorf("unknown group %q, use %s, %s, %s or %s",
+				group, entity.OrderCounted, entity.OrderPending, entity.OrderCancelled, entity.OrderReturned))
+		}
+		for _, status := range statuses {
+			if previous, ok := cfg.orderStatuses[status]; ok {
+				r.fail("orders.statuses", fmt.Errorf("status %d is both %s and %s", status, previous, group))
+			}
+			cfg.orderStatuses[status] = group
+		}
+	}
+	viper.SetDefault("orders.paidPath", "data/paid_orders.json")
+	cfg.paidOrdersPath = r.getString("orders.paidPath")
+	viper.SetDefault("orders.pendingPath", "data/pending_orders.json")
+	cfg.pendingOrdersPath = r.getString("orders.pendingPath")
+	cfg.orderColumns = database.OrderColumns{Amount: r.getString("orders.amountColumn")}
+	r.fail("orders.amountColumn", cfg.orderColumns.Validate())
+
+	viper.SetDefault("talkTime.shortCallSeconds", 20)
+	cfg.talkTimeSettings = service.TalkTimeSettings{
+		ShortCall:                 time.Duration(r.getFloat("talkTime.shortCallSeconds") * float64(time.Second)),
+		BonusMaxShortCallsShare:   r.getFloat("talkTime.bonusMaxShortCallsShare"),
+		BonusMinAverageHandleTime: time.Duration(r.getFloat("talkTime.bonusMinAverageHandleSeconds") * float64(time.Second)),
+		Measured: entity.MeasuredDurations{
+			TalkTime: cfg.durationColumns.TalkTime != "",
+			WaitTime: cfg.durationColumns.WaitTime != "",
+			RingTime: cfg.durationColumns.RingTime != "",
+		},
+	}
+	r.fail("talkTime", cfg.talkTimeSettings.Validate())
+	//without the column every call has zero talk time and the conditions would withhold every bonus
+	if !cfg.talkTimeSettings.Measured.TalkTime {
+		for _, key := range []string{"talkTime.bonusMaxShortCallsShare", "talkTime.bonusMinAverageHandleSeconds"} {
+			if viper.GetFloat64(key) > 0 {
+				r.fail(key, errors.New("requires mango.talkTimeColumn"))
+			}
+		}
+	}
+
+	cfg.sourceLines = r.getSourceLines()
+
+	var spendConfig []struct {
+		Source string
+		From   string
+		To     string
+		Amount float64
+	}
+	r.unmarshal("marketing.spend", &spendConfig)
+	for _, spend := range spendConfig {
+		cfg.adSpends = append(cfg.adSpends, entity.AdSpend{
+			Source:   spend.Source,
+			DateFrom: r.getDate("marketing.spend", spend.From, cfg.businessLocation),
+			DateTo:   r.getDate("marketing.spend", spend.To, cfg.businessLocation),
+			Amount:   spend.Amount,
+		})
+	}
+
+	viper.SetDefault("expenses", []map[string]interface{}{
+		{"name": "Манго", "prompt": true},
+		{"name": "Оплата СМС сервиса", "prompt": true},
+	})
+	r.unmarshal("expenses", &cfg.recurringExpenses)
+
+	cfg.calendar.Statistics = r.getPeriodDefinition("periods.statistics", cfg.businessLocation)
+	cfg.calendar.Payroll = r.getPeriodDefinition("periods.payroll", cfg.businessLocation)
+	var namedPeriods []struct {
+		Name string
+		From string
+		To   string
+	}
+	r.unmarshal("periods.named", &namedPeriods)
+	cfg.calendar.Named = make(map[string]period.Period, len(namedPeriods))
+	for _, named := range namedPeriods {
+		key := fmt.Sprintf("periods.named %q", named.Name)
+		dateFrom := r.getDate(key, named.From, cfg.businessLocation)
+		dateTo := r.getDate(key, named.To, cfg.businessLocation)
+		if dateTo.Before(dateFrom) {
+			r.fail(key, errors.New("the end is before the start"))
+		}
+		cfg.calendar.Named[named.Name] = period.Period{From: dateFrom, To: dateTo}
+	}
+
+	cfg.motivationMap = r.getFloatMap("salary.motivationMap")
+
+	//with tiers the orders are paid a percent of their amount instead of salary.orderFee
+	cfg.commissionTiers = r.getFloatMap("salary.commissionTiers")
+	r.fail("salary.commissionTiers", cfg.commissionTiers.Validate())
+	if len(cfg.commissionTiers) > 0 && cfg.orderColumns.Amount == "" {
+		r.fail("salary.commissionTiers", errors.New("need orders.amountColumn"))
+	}
+
+	return cfg, errors.Join(r.errs...)
+}
+
+// getCityLines reads the cities, they are matched by priority and then in the listed order, the older map is matched
+// in the order of names. The report keeps the listed order with the cities of a region next to each other
+func (r *configReader) getCityLines() service.CityLines {
+	type cityConfig struct {
+		Name     string
+		Lines    string
+		Priority int
+		Region   string
+		CrmNames []string
+	}
+	var citiesConfig []cityConfig
+	r.unmarshal("cities", &citiesConfig)
+	if len(citiesConfig) == 0 {
+		citiesAndLines := viper.GetStringMapString("citiesAndLinesRegexpMap")
+		cityNames := make([]string, 0, len(citiesAndLines))
+		for city := range citiesAndLines {
+			cityNames = append(cityNames, city)
+		}
+		sort.Strings(cityNames)
+		for _, city := range cityNames {
+			citiesConfig = append(citiesConfig, cityConfig{Name: cases.Title(language.Russian).String(city), Lines: citiesAndLines[city]})
+		}
+	}
+
+	cityLines := make(service.CityLines, 0, len(citiesConfig))
+	for i, city := range citiesConfig {
+		if city.Name == "" {
+			r.fail(fmt.Sprintf("cities[%d]", i), errors.New("a city without name"))
+			continue
+		}
+		rExp, err := regexp.Compile(city.Lines)
+		if err != nil {
+			r.fail(fmt.Sprintf("cities %q lines", city.Name), err)
+			continue
+		}
+		cityLines = append(cityLines, service.CityLine{City: city.Name, Line: rExp, Priority: city.Priority,
+			Region: city.Region, CrmNames: city.CrmNames})
+	}
+	r.fail("cities", cityLines.Validate())
+	return cityLines
+}
+
+// getSourceLines reads the marketing sources, they are matched like the cities. The older map of source names
+// to regexps is matched in the order of names
+func (r *configReader) getSourceLines() []service.SourceLine {
+	type sourceConfig struct {
+		Name     string
+		Lines    string
+		Priority int
+	}
+	var sourcesConfig []sourceConfig
+	if _, isMap := viper.Get("marketing.sources").(map[string]interface{}); isMap {
+		sourcesAndLines := viper.GetStringMapString("marketing.sources")
+		sourceNames := make([]string, 0, len(sourcesAndLines))
+		for source := range sourcesAndLines {
+			sourceNames = append(sourceNames, source)
+		}
+		sort.Strings(sourceNames)
+		for _, source := range sourceNames {
+			sourcesConfig = append(sourcesConfig, sourceConfig{Name: source, Lines: sourcesAndLines[source]})
+		}
+	} else {
+		r.unmarshal("marketing.sources", &sourcesConfig)
+	}
+
+	sourceLines := make([]service.SourceLine, 0, len(sourcesConfig))
+	for i, source := range sourcesConfig {
+		if source.Name == "" {
+			r.fail(fmt.Sprintf("marketing.sources[%d]", i), errors.New("a source without name"))
+			continue
+		}
+		rExp, err := regexp.Compile(source.Lines)
+		if err != nil {
+			r.fail(fmt.Sprintf("marketing.sources %q lines", source.Name), err)
+			continue
+		}
+		sourceLines = append(sourceLines, service.SourceLine{Source: source.Name, Line: rExp, Priority: source.Priority})
+	}
+	return sourceLines
+}
+
+// getPeriodDefinition reads {type, weekStart, anchor} under the key, ISO weeks by default
+func (r *configReader) getPeriodDefinition(key string, location *time.Location) period.Definition {
+	viper.SetDefault(key+".type", period.IsoWeek)
+	viper.SetDefault(key+".weekStart", time.Monday.String())
+
+	definition := period.Definition{Type: r.getString(key + ".type")}
+	weekStart, err := period.ParseWeekday(r.getString(key + ".weekStart"))
+	r.fail(key+".weekStart", err)
+	definition.WeekStart = weekStart
+	if anchor := r.getString(key + ".anchor"); anchor != "" {
+		definition.Anchor = r.getDate(key+".anchor", anchor, location)
+	}
+	if err == nil {
+		r.fail(key, definition.Validate())
+	}
+	return definition
+}
+
+// clockToCron converts the "15:04" report time to the minute and hour of a cron expression, the week days are appended
+func clockToCron(clock string) (string, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return "", fmt.Errorf("report time %q: %w", clock, err)
+	}
+	return fmt.Sprintf("%d %d * * ", t.Minute(), t.Hour()), nil
+}
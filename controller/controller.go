@@ -2,32 +2,83 @@ package controller
 
 import (
 	"callCenterReportMaker/entity"
+	"callCenterReportMaker/period"
 	"callCenterReportMaker/repository/database"
+	"callCenterReportMaker/repository/paidOrders"
+	"callCenterReportMaker/repository/shifts"
 	"callCenterReportMaker/service"
+	"callCenterReportMaker/statImage"
+	"callCenterReportMaker/templates"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	historyFrameDays = 90
+	confirmAnswer    = "да"
+)
+
+var errDataRejected = errors.New("расчет отменен, данные не подтверждены")
+
 type controller struct {
-	srv service.Service
-	db  database.Database
+	srv      service.Service
+	db       database.Database
+	shifts   shifts.Shifts
+	ledger   paidOrders.PaidOrders
+	painter  statImage.Painter
+	tmpl     templates.Templates
+	location *time.Location
+	calendar period.Calendar
+}
+
+// statisticsData is what the statistics message template is executed with
+type statisticsData struct {
+	DateFrom, DateTo time.Time
+	Operators        []entity.DatabaseStatistic
+	WildOrders       entity.DatabaseStatistic
+	Total            entity.DatabaseStatistic
+	Attainment       entity.GradeAttainment
 }
 type Controller interface {
-	MakeReport(dateFrom, dateTo time.Time, readWriter io.ReadWriter) (entity.WeeklyReport, error)
+	MakeReport(dateFrom, dateTo time.Time, inputs entity.ReportInputs, readWriter io.ReadWriter) (entity.WeeklyReport, error)
 	MakeWeeklyConversionStatistics() string
+	MakePeriodStatistics(dateFrom, dateTo time.Time) string
+	MakeWeeklyConversionImages() ([][]byte, error)
+	MakeGradeAttainment() string
+	GetDefaultExpenses() []entity.ExpenseItem
+	MakeJourneyReport(dateFrom, dateTo time.Time) (string, error)
+	MakeMarketingReport(dateFrom, dateTo time.Time) (string, error)
+	MakeLinkageReport(dateFrom, dateTo time.Time) (string, error)
+	MakeLineMatchReport(dateFrom, dateTo time.Time) (string, error)
+	CheckAnomalies() (string, error)
+	ImportShifts(source string) (string, error)
+	ApprovePayroll(report entity.WeeklyReport) error
+	Reload(srv service.Service, operatorsNames []string)
 }
 
-func New(srv service.Service, db database.Database) Controller {
-	return controller{
-		srv: srv,
-		db:  db,
-	}
+func New(srv service.Service, db database.Database, shiftsRepository shifts.Shifts, ledger paidOrders.PaidOrders, painter statImage.Painter, tmpl templates.Templates,
+	location *time.Location, calendar period.Calendar) Controller {
+	r := &reloadable{}
+	r.current.Store(&controller{
+		srv:      srv,
+		db:       db,
+		shifts:   shiftsRepository,
+		ledger:   ledger,
+		painter:  painter,
+		tmpl:     tmpl,
+		location: location,
+		calendar: calendar,
+	})
+	return r
 }
 
-func (c controller) MakeReport(dateFrom, dateTo time.Time, readWriter io.ReadWriter) (entity.WeeklyReport, error) {
+func (c controller) MakeReport(dateFrom, dateTo time.Time, inputs entity.ReportInputs, readWriter io.ReadWriter) (entity.WeeklyReport, error) {
 	uniqCallsByOperators, err := c.db.GetUniqCallsByOperators(dateFrom, dateTo)
 	if err != nil {
 		return entity.WeeklyReport{}, err
@@ -37,33 +88,337 @@ func (c controller) MakeReport(dateFrom, dateTo time.Time, readWriter io.ReadWri
 		return entity.WeeklyReport{}, err
 	}
 
-	callHistory, err := c.db.GetHistory(90)
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return entity.WeeklyReport{}, err
+	}
+
+	schedule, err := c.shifts.GetShifts(dateFrom, dateTo)
 	if err != nil {
 		return entity.WeeklyReport{}, err
 	}
 
-	weeklyReport := c.srv.GetWeeklyReport(uniqCallsByOperators, orders, callHistory, dateFrom, dateTo, readWriter)
+	clawbacks, err := c.getClawbacks()
+	if err != nil {
+		return entity.WeeklyReport{}, err
+	}
+
+	pending, err := c.getPendingOrders(dateFrom, dateTo)
+	if err != nil {
+		return entity.WeeklyReport{}, err
+	}
+
+	dataIssues := c.srv.ValidateData(orders, callHistory, dateFrom, dateTo)
+	if len(dataIssues) > 0 && !inputs.AcceptDataIssues && !c.confirmDataIssues(dataIssues, readWriter) {
+		return entity.WeeklyReport{}, errDataRejected
+	}
+
+	weeklyReport := c.srv.GetWeeklyReport(uniqCallsByOperators, orders, callHistory, schedule, clawbacks, pending, dateFrom, dateTo, inputs, readWriter)
+	weeklyReport.DataIssues = dataIssues
 	return weeklyReport, err
 }
+
+// confirmDataIssues shows the data check summary and asks the admin whether to compute the payroll anyway
+func (c controller) confirmDataIssues(dataIssues []entity.DataIssue, readWriter io.ReadWriter) bool {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString("Проверка данных нашла проблемы:\n")
+	for _, issue := range dataIssues {
+		strBuilder.WriteString(issue.String() + "\n")
+	}
+	strBuilder.WriteString(fmt.Sprintf("Считать зарплату по этим данным? Отправьте \"%s\", чтобы продолжить", confirmAnswer))
+	_, _ = readWriter.Write([]byte(strBuilder.String()))
+
+	buf := make([]byte, 1024)
+	n, _ := readWriter.Read(buf)
+	return strings.EqualFold(strings.TrimSpace(string(buf[:n])), confirmAnswer)
+}
+
+// getClawbacks checks the current status of the orders paid in the approved payrolls
+func (c controller) getClawbacks() ([]entity.Clawback, error) {
+	paid, err := c.ledger.GetPaidOrders()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(paid))
+	for _, order := range paid {
+		ids = append(ids, order.Id)
+	}
+	statuses, err := c.db.GetOrderStatuses(ids)
+	if err != nil {
+		return nil, err
+	}
+	return c.srv.GetClawbacks(paid, statuses), nil
+}
+
+// getPendingOrders refreshes the status of the pending orders of the approved payrolls and adds the period's pending orders
+func (c controller) getPendingOrders(dateFrom, dateTo time.Time) ([]entity.PendingOrder, error) {
+	pending, err := c.ledger.GetPendingOrders()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(pending))
+	for _, order := range pending {
+		ids = append(ids, order.Id)
+	}
+	statuses, err := c.db.GetOrderStatuses(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pending {
+		pending[i].Status = statuses[pending[i].Id]
+	}
+
+	orders, err := c.db.GetPendingOrders(dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		pending = append(pending, entity.PendingOrder{Orders: order, DateFrom: dateFrom, DateTo: dateTo})
+	}
+	return pending, nil
+}
+
+// ApprovePayroll records the report's orders as paid, its clawbacks as deducted and the orders still pending
+func (c controller) ApprovePayroll(report entity.WeeklyReport) error {
+	return c.ledger.Settle(report.PaidOrders, report.Clawbacks, report.PendingOrders)
+}
+func (c controller) GetDefaultExpenses() []entity.ExpenseItem {
+	return c.srv.GetDefaultExpenses()
+}
+func (c controller) MakeJourneyReport(dateFrom, dateTo time.Time) (string, error) {
+	orders, err := c.db.GetOrders(dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return "", err
+	}
+
+	return c.srv.GetJourneyReport(orders, callHistory, dateFrom, dateTo).String(), nil
+}
+func (c controller) MakeMarketingReport(dateFrom, dateTo time.Time) (string, error) {
+	orders, err := c.db.GetOrders(dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return "", err
+	}
+
+	return c.srv.GetMarketingReport(orders, callHistory, dateFrom, dateTo).String(), nil
+}
+func (c controller) MakeLinkageReport(dateFrom, dateTo time.Time) (string, error) {
+	orders, err := c.db.GetOrders(dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return "", err
+	}
+
+	return c.srv.GetLinkageReport(orders, callHistory, dateFrom, dateTo).String(), nil
+}
+func (c controller) MakeLineMatchReport(dateFrom, dateTo time.Time) (string, error) {
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return "", err
+	}
+
+	return c.srv.GetLineMatchReport(callHistory, dateFrom, dateTo).String(), nil
+}
+func (c controller) CheckAnomalies() (string, error) {
+	today := c.today()
+	checkedDay := today.AddDate(0, 0, -1)
+
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return "", err
+	}
+
+	orders, err := c.db.GetOrders(today.AddDate(0, 0, -historyFrameDays), today)
+	if err != nil {
+		return "", err
+	}
+
+	operatorCalls, err := c.db.GetOperatorCalls(today.AddDate(0, 0, -historyFrameDays), today)
+	if err != nil {
+		return "", err
+	}
+
+	//night shifts of the previous day reach into the checked one
+	schedule, err := c.shifts.GetShifts(checkedDay.AddDate(0, 0, -1), checkedDay)
+	if err != nil {
+		return "", err
+	}
+
+	anomalies := c.srv.DetectAnomalies(callHistory, operatorCalls, orders, schedule, checkedDay)
+	if len(anomalies) == 0 {
+		return "", nil
+	}
+
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("Внимание, отклонения за %s:\n", checkedDay.Format("02.01.2006")))
+	for _, anomaly := range anomalies {
+		strBuilder.WriteString(anomaly.String() + "\n")
+	}
+	return strBuilder.String(), nil
+}
+
+// ImportShifts stores the uploaded schedule, the reply tells how many shifts it had
+func (c controller) ImportShifts(source string) (string, error) {
+	imported, err := c.shifts.Import(source)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Загружено смен: %d", imported), nil
+}
 func (c controller) MakeWeeklyConversionStatistics() string {
-	year, month, day := time.Now().Date()
+	dbStats, _, dateFrom, dateTo := c.getCurrentPeriodStatistics()
+	attainment := c.getGradeAttainment(dbStats, dateFrom, dateTo)
 
-	dateTo := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-	dateFrom := getFirstDayOfCurrentWeek(dateTo)
+	message, err := c.executeStatisticsTemplate(dbStats, attainment, dateFrom, dateTo)
+	if err != nil {
+		log.Println(err)
+		return dbStatPrettyString(dbStats, dateFrom, dateTo) + "\n" + attainment.String()
+	}
+	return message
+}
+
+// MakePeriodStatistics makes the statistics message for a closed period, so nothing is projected
+func (c controller) MakePeriodStatistics(dateFrom, dateTo time.Time) string {
+	dbStats, _ := c.getStatistics(dateFrom, dateTo)
+	attainment := c.srv.GetGradeAttainment(dbStats[len(dbStats)-1], 0, 0)
+
+	message, err := c.executeStatisticsTemplate(dbStats, attainment, dateFrom, dateTo)
+	if err != nil {
+		log.Println(err)
+		return dbStatPrettyString(dbStats, dateFrom, dateTo) + "\n" + attainment.String()
+	}
+	return message
+}
+
+func (c controller) executeStatisticsTemplate(dbStats []entity.DatabaseStatistic, attainment entity.GradeAttainment, dateFrom, dateTo time.Time) (string, error) {
+	tmpl, err := c.tmpl.Statistics()
+	if err != nil {
+		return "", err
+	}
+
+	//the last two rows are wild orders and the department total
+	data := statisticsData{
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+		Operators:  make([]entity.DatabaseStatistic, 0, len(dbStats)),
+		Attainment: attainment,
+	}
+	for i, dbStat := range dbStats {
+		switch i {
+		case len(dbStats) - 2:
+			data.WildOrders = dbStat
+		case len(dbStats) - 1:
+			data.Total = dbStat
+		default:
+			if dbStat.OrdersCount != 0 || dbStat.UniqOutgoingCalls != 0 || dbStat.UniqIncomingCalls != 0 {
+				data.Operators = append(data.Operators, dbStat)
+			}
+		}
+	}
 
+	strBuilder := strings.Builder{}
+	if err = tmpl.Execute(&strBuilder, data); err != nil {
+		return "", err
+	}
+	return strBuilder.String(), nil
+}
+
+func (c controller) MakeGradeAttainment() string {
+	dbStats, _, dateFrom, dateTo := c.getCurrentPeriodStatistics()
+
+	return c.getGradeAttainment(dbStats, dateFrom, dateTo).String()
+}
+
+func (c controller) MakeWeeklyConversionImages() ([][]byte, error) {
+	dbStats, orders, dateFrom, dateTo := c.getCurrentPeriodStatistics()
+
+	callHistory, err := c.db.GetHistory(historyFrameDays)
+	if err != nil {
+		return nil, err
+	}
+	cityStats := c.srv.GetCityStatistics(orders, callHistory, dateFrom, dateTo)
+
+	dateLayout := "02.01.2006"
+	title := fmt.Sprintf("с %s по %s", dateFrom.Format(dateLayout), dateTo.Format(dateLayout))
+
+	rows := make([][]string, 0, len(dbStats))
+	operatorBars := make([]statImage.Bar, 0, len(dbStats))
+	for i, stat := range dbStats {
+		if i == len(dbStats)-2 {
+			rows = append(rows, []string{stat.Operator, strconv.Itoa(stat.OrdersCount)})
+			continue
+		}
+		if stat.OrdersCount == 0 && stat.UniqOutgoingCalls == 0 && stat.UniqIncomingCalls == 0 {
+			continue
+		}
+		conversion := fmt.Sprintf("%.4g%%", stat.Conversion*100)
+		rows = append(rows, []string{stat.Operator, strconv.Itoa(stat.OrdersCount),
+			strconv.Itoa(stat.UniqIncomingCalls), strconv.Itoa(stat.UniqOutgoingCalls), conversion})
+		operatorBars = append(operatorBars, statImage.Bar{Label: stat.Operator, Value: stat.Conversion, Text: conversion})
+	}
+
+	cityBars := make([]statImage.Bar, 0, len(cityStats))
+	for _, stat := range cityStats {
+		cityBars = append(cityBars, statImage.Bar{Label: stat.City, Value: stat.Conversion, Text: fmt.Sprintf("%.4g%%", stat.Conversion*100)})
+	}
+
+	table, err := c.painter.Table("Отчет по операторам "+title, []string{"ФИО", "заказы", "ун.вх.", "ун.исх.", "конв."}, rows)
+	if err != nil {
+		return nil, err
+	}
+	operatorChart, err := c.painter.BarChart("Конверсия по операторам "+title, operatorBars)
+	if err != nil {
+		return nil, err
+	}
+	cityChart, err := c.painter.BarChart("Конверсия по городам "+title, cityBars)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{table, operatorChart, cityChart}, nil
+}
+
+func (c controller) getCurrentPeriodStatistics() (dbStats []entity.DatabaseStatistic, orders []entity.Orders, dateFrom, dateTo time.Time) {
+	dateTo = c.today()
+	dateFrom = c.calendar.Statistics.Containing(dateTo).From
+
+	dbStats, orders = c.getStatistics(dateFrom, dateTo)
+	return dbStats, orders, dateFrom, dateTo
+}
+
+func (c controller) getStatistics(dateFrom, dateTo time.Time) (dbStats []entity.DatabaseStatistic, orders []entity.Orders) {
 	callsByOperator, err := c.db.GetUniqCallsByOperators(dateFrom, dateTo)
 	if err != nil {
 		log.Println(err)
 	}
 
-	orders, err := c.db.GetOrders(dateFrom, dateTo)
+	orders, err = c.db.GetOrders(dateFrom, dateTo)
 	if err != nil {
 		log.Println(err)
 	}
 
-	dbStats := c.srv.GetDatabaseStatistic(callsByOperator, orders)
-	message := dbStatPrettyString(dbStats, dateFrom, dateTo)
-	return message
+	dbStats = c.srv.GetDatabaseStatistic(callsByOperator, orders)
+	return dbStats, orders
+}
+
+func (c controller) getGradeAttainment(dbStats []entity.DatabaseStatistic, dateFrom, dateTo time.Time) entity.GradeAttainment {
+	daysPassed := int(math.Round(dateTo.Sub(dateFrom).Hours() / 24))
+	daysLeft := c.calendar.Statistics.Containing(dateFrom).Days() - daysPassed
+
+	return c.srv.GetGradeAttainment(dbStats[len(dbStats)-1], daysPassed, daysLeft)
 }
 
 func dbStatPrettyString(dbStats []entity.DatabaseStatistic, dateFrom, dateTo time.Time) string {
@@ -88,21 +443,9 @@ func dbStatPrettyString(dbStats []entity.DatabaseStatistic, dateFrom, dateTo tim
 	str := strBuilder.String()
 	return str
 }
-func getFirstDayOfCurrentWeek(startDate time.Time) time.Time {
-	switch startDate.Weekday() {
-	case time.Tuesday:
-		return startDate.AddDate(0, 0, -1)
-	case time.Wednesday:
-		return startDate.AddDate(0, 0, -2)
-	case time.Thursday:
-		return startDate.AddDate(0, 0, -3)
-	case time.Friday:
-		return startDate.AddDate(0, 0, -4)
-	case time.Saturday:
-		return startDate.AddDate(0, 0, -5)
-	case time.Sunday:
-		return startDate.AddDate(0, 0, -6)
-	default:
-		return startDate
-	}
+
+// today returns the midnight of the current day in the business location
+func (c controller) today() time.Time {
+	year, month, day := time.Now().In(c.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location)
 }
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/service"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// reloadable runs every call on the controller current at the call start,
+// so a report is never made half with the old and half with the reloaded config
+type reloadable struct {
+	current atomic.Pointer[controller]
+}
+
+// Reload swaps the service and the operators of the database and shifts for the next calls
+func (r *reloadable) Reload(srv service.Service, operatorsNames []string) {
+	c := *r.current.Load()
+	c.srv = srv
+	c.db = c.db.WithOperators(operatorsNames)
+	c.shifts = c.shifts.WithOperators(operatorsNames)
+	r.current.Store(&c)
+}
+
+func (r *reloadable) MakeReport(dateFrom, dateTo time.Time, inputs entity.ReportInputs, readWriter io.ReadWriter) (entity.WeeklyReport, error) {
+	return r.current.Load().MakeReport(dateFrom, dateTo, inputs, readWriter)
+}
+
+func (r *reloadable) MakeWeeklyConversionStatistics() string {
+	return r.current.Load().MakeWeeklyConversionStatistics()
+}
+
+func (r *reloadable) MakePeriodStatistics(dateFrom, dateTo time.Time) string {
+	return r.current.Load().MakePeriodStatistics(dateFrom, dateTo)
+}
+
+func (r *reloadable) MakeWeeklyConversionImages() ([][]byte, error) {
+	return r.current.Load().MakeWeeklyConversionImages()
+}
+
+func (r *reloadable) MakeGradeAttainment() string {
+	return r.current.Load().MakeGradeAttainment()
+}
+
+func (r *reloadable) GetDefaultExpenses() []entity.ExpenseItem {
+	return r.current.Load().GetDefaultExpenses()
+}
+
+func (r *reloadable) MakeJourneyReport(dateFrom, dateTo time.Time) (string, error) {
+	return r.current.Load().MakeJourneyReport(dateFrom, dateTo)
+}
+
+func (r *reloadable) MakeMarketingReport(dateFrom, dateTo time.Time) (string, error) {
+	return r.current.Load().MakeMarketingReport(dateFrom, dateTo)
+}
+
+func (r *reloadable) MakeLinkageReport(dateFrom, dateTo time.Time) (string, error) {
+	return r.current.Load().MakeLinkageReport(dateFrom, dateTo)
+}
+
+func (r *reloadable) MakeLineMatchReport(dateFrom, dateTo time.Time) (string, error) {
+	return r.current.Load().MakeLineMatchReport(dateFrom, dateTo)
+}
+
+func (r *reloadable) CheckAnomalies() (string, error) {
+	return r.current.Load().CheckAnomalies()
+}
+
+func (r *reloadable) ImportShifts(source string) (string, error) {
+	return r.current.Load().ImportShifts(source)
+}
+
+func (r *reloadable) ApprovePayroll(report entity.WeeklyReport) error {
+	return r.current.Load().ApprovePayroll(report)
+}
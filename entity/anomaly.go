@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AnomalyZeroActivity       = "zeroActivity"
+	AnomalyDrop               = "drop"
+	AnomalyOrdersWithoutCalls = "ordersWithoutCalls"
+	AnomalyCallsOffShift      = "callsOffShift"
+)
+
+type Anomaly struct {
+	Kind     string
+	Subject  string
+	Date     time.Time
+	Value    int
+	Baseline float64
+}
+
+func (a Anomaly) String() string {
+	switch a.Kind {
+	case AnomalyZeroActivity:
+		return fmt.Sprintf("%s: ноль, обычно %.1f в день", a.Subject, a.Baseline)
+	case AnomalyDrop:
+		return fmt.Sprintf("%s: %d, обычно %.1f в день", a.Subject, a.Value, a.Baseline)
+	case AnomalyOrdersWithoutCalls:
+		return fmt.Sprintf("%s: %d заказов без единого звонка", a.Subject, a.Value)
+	case AnomalyCallsOffShift:
+		return fmt.Sprintf("%s: %d звонков вне смены по графику", a.Subject, a.Value)
+	default:
+		return fmt.Sprintf("%s: %d", a.Subject, a.Value)
+	}
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "fmt"
+
+const (
+	DataInvalidDate      = "invalidDate"
+	DataUnknownOperator  = "unknownOperator"
+	DataUnknownCity      = "unknownCity"
+	DataAmbiguousCity    = "ambiguousCity"
+	DataOrderOutOfPeriod = "orderOutOfPeriod"
+	DataDuplicateOrder   = "duplicateOrder"
+	DataUnmatchedLine    = "unmatchedLine"
+	DataAmbiguousLine    = "ambiguousLine"
+)
+
+// DataIssue is a problem found in the fetched data before the payroll is computed
+type DataIssue struct {
+	Kind    string
+	Subject string
+	Count   int
+}
+
+func (i DataIssue) String() string {
+	switch i.Kind {
+	case DataInvalidDate:
+		return fmt.Sprintf("%s с нечитаемой датой: %d", i.Subject, i.Count)
+	case DataUnknownOperator:
+		return fmt.Sprintf("Оператора %q нет в списке, его заказы не попадут в расчет: %d", i.Subject, i.Count)
+	case DataUnknownCity:
+		return fmt.Sprintf("Город %q не найден в настройках, заказов: %d", i.Subject, i.Count)
+	case DataAmbiguousCity:
+		return fmt.Sprintf("Город %s подходит нескольким городам, заказы отнесены к первому, задайте crmNames: %d", i.Subject, i.Count)
+	case DataOrderOutOfPeriod:
+		return fmt.Sprintf("Заказов с датой вне периода отчета: %d", i.Count)
+	case DataDuplicateOrder:
+		return fmt.Sprintf("Заказ №%s повторяется, записей: %d", i.Subject, i.Count)
+	case DataUnmatchedLine:
+		return fmt.Sprintf("Линия %s не подходит ни одному городу, звонков: %d", i.Subject, i.Count)
+	case DataAmbiguousLine:
+		return fmt.Sprintf("Линия %s подходит нескольким городам, звонки отнесены к первому: %d", i.Subject, i.Count)
+	default:
+		return fmt.Sprintf("%s: %d", i.Subject, i.Count)
+	}
+}
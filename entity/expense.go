@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ExpenseItem struct {
+	Name   string
+	Amount float64
+}
+
+// ParseExpenseItem reads "name amount", the amount is the last word so names may contain spaces
+func ParseExpenseItem(str string) (ExpenseItem, error) {
+	separator := strings.LastIndexAny(str, " \t=")
+	if separator <= 0 {
+		return ExpenseItem{}, fmt.Errorf("не понял статью расходов %q, нужно \"название сумма\"", str)
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(str[separator+1:], ",", "."), 64)
+	if err != nil {
+		return ExpenseItem{}, fmt.Errorf("не понял сумму в %q: %w", str, err)
+	}
+	return ExpenseItem{Name: strings.TrimSpace(str[:separator]), Amount: amount}, nil
+}
+
+// ParseExpenses reads expense items separated by ';', e.g. "Манго=15000;Гарнитуры=3000"
+func ParseExpenses(str string) ([]ExpenseItem, error) {
+	expenses := make([]ExpenseItem, 0)
+	for _, item := range strings.Split(str, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		expense, err := ParseExpenseItem(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses, nil
+}
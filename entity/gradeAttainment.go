@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+type GradeAttainment struct {
+	OrdersCount                 int
+	UniqCalls                   int
+	Conversion                  float64
+	GradeReached                bool
+	CurrentGrade                float64
+	CurrentBonusPerOrder        float64
+	HasNextGrade                bool
+	NextGrade                   float64
+	NextBonusPerOrder           float64
+	OrdersToNextGrade           int
+	ConversionPointsToNextGrade float64
+	DaysPassed                  int
+	DaysLeft                    int
+	ProjectedOrders             int
+	ProjectedCalls              int
+	ProjectedOrdersToNextGrade  int
+	OrdersPerDayToNextGrade     float64
+}
+
+func (g GradeAttainment) String() string {
+	strBuilder := strings.Builder{}
+
+	strBuilder.WriteString(fmt.Sprintf("Конверсия отдела %.4g%% (%d заказов / %d ун. звонков)\n",
+		g.Conversion*100, g.OrdersCount, g.UniqCalls))
+
+	if g.GradeReached {
+		strBuilder.WriteString(fmt.Sprintf("Текущий грейд: свыше %g%%, премия %g руб. за заказ\n",
+			g.CurrentGrade, g.CurrentBonusPerOrder))
+	} else {
+		strBuilder.WriteString("Текущий грейд: не достигнут\n")
+	}
+
+	if !g.HasNextGrade {
+		strBuilder.WriteString("Достигнут максимальный грейд\n")
+		return strBuilder.String()
+	}
+
+	strBuilder.WriteString(fmt.Sprintf("Следующий грейд: свыше %g%%, премия %g руб. за заказ\n",
+		g.NextGrade, g.NextBonusPerOrder))
+	strBuilder.WriteString(fmt.Sprintf("До него: %d заказов или %.2f п.п. конверсии\n",
+		g.OrdersToNextGrade, g.ConversionPointsToNextGrade))
+
+	if g.DaysPassed > 0 {
+		strBuilder.WriteString(fmt.Sprintf("Прогноз на конец периода: %d заказов / %d ун. звонков\n",
+			g.ProjectedOrders, g.ProjectedCalls))
+		if g.DaysLeft > 0 {
+			strBuilder.WriteString(fmt.Sprintf("Для следующего грейда нужно еще %d заказов, %.1f в день (осталось дней: %d)\n",
+				g.ProjectedOrdersToNextGrade, g.OrdersPerDayToNextGrade, g.DaysLeft))
+		}
+	}
+
+	return strBuilder.String()
+}
@@ -7,4 +7,7 @@ type HistoryRecord struct {
 	Abonent    string
 	Operator   string
 	LineNumber string
+	TalkTime   time.Duration
+	WaitTime   time.Duration
+	RingTime   time.Duration
 }
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type JourneyReport struct {
+	DateFrom, DateTo       time.Time
+	LinkedOrders           int
+	UnlinkedOrders         int
+	AvgContactsBeforeOrder float64
+	CityJourneys           []CityJourney
+}
+
+type CityJourney struct {
+	City                   string
+	Callers                int
+	RepeatCallers          int
+	NewCallers             int
+	ReturningCallers       int
+	NewCallersOrders       int
+	ReturningCallersOrders int
+	NewConversion          float64
+	ReturningConversion    float64
+}
+
+func (r JourneyReport) String() string {
+	strBuilder := strings.Builder{}
+	dateLayout := "02.01.2006"
+
+	strBuilder.WriteString(fmt.Sprintf("Путь клиента за период с %s по %s\n",
+		r.DateFrom.Format(dateLayout), r.DateTo.Format(dateLayout)))
+	strBuilder.WriteString(fmt.Sprintf("Заказов со звонком: %d, без звонка: %d\n", r.LinkedOrders, r.UnlinkedOrders))
+	strBuilder.WriteString(fmt.Sprintf("Звонков до заказа в среднем: %.2f\n", r.AvgContactsBeforeOrder))
+	strBuilder.WriteString(fmt.Sprintf("%-12s %-6s %-6s %-6s %-7s %-7s %s\n",
+		"Город", "абон.", "повт.", "нов.", "вернув.", "конв.н.", "конв.в."))
+
+	for _, city := range r.CityJourneys {
+		strBuilder.WriteString(city.String() + "\n")
+	}
+
+	return strBuilder.String()
+}
+
+func (c CityJourney) String() string {
+	return fmt.Sprintf("%-12s %-6d %-6d %-6d %-7d %-7s %s", c.City, c.Callers, c.RepeatCallers, c.NewCallers, c.ReturningCallers,
+		fmt.Sprintf("%.4g%%", c.NewConversion*100), fmt.Sprintf("%.4g%%", c.ReturningConversion*100))
+}
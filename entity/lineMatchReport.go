@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LineCalls counts the calls to a line, Matches are the matching cities or sources with the one the calls go to first
+type LineCalls struct {
+	LineNumber string
+	Matches    []string
+	Calls      int
+}
+
+type LineMatchReport struct {
+	DateFrom, DateTo time.Time
+	Unmatched        []LineCalls
+	Ambiguous        []LineCalls
+}
+
+func (r LineMatchReport) String() string {
+	strBuilder := strings.Builder{}
+	dateLayout := "02.01.2006"
+
+	strBuilder.WriteString(fmt.Sprintf("Линии без города за период с %s по %s\n",
+		r.DateFrom.Format(dateLayout), r.DateTo.Format(dateLayout)))
+	if len(r.Unmatched) == 0 {
+		strBuilder.WriteString("нет\n")
+	}
+	for _, line := range r.Unmatched {
+		strBuilder.WriteString(fmt.Sprintf("%-14s %d зв.\n", line.LineNumber, line.Calls))
+	}
+
+	if len(r.Ambiguous) > 0 {
+		strBuilder.WriteString("\nЛинии, подходящие нескольким городам, звонки отнесены к первому\n")
+		for _, line := range r.Ambiguous {
+			strBuilder.WriteString(fmt.Sprintf("%-14s %d зв. %s\n", line.LineNumber, line.Calls, strings.Join(line.Matches, ", ")))
+		}
+	}
+
+	return strBuilder.String()
+}
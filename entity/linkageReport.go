@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type LinkedOrder struct {
+	Order          Orders
+	Call           HistoryRecord
+	Linked         bool
+	ContactsBefore int
+}
+
+type LinkageReport struct {
+	DateFrom, DateTo    time.Time
+	LinkedOrdersCount   int
+	UnlinkedOrdersCount int
+	CityStatistics      []LinkedStatistic
+	LineStatistics      []LinkedStatistic
+}
+
+type LinkedStatistic struct {
+	Name        string
+	UniqCalls   int
+	OrdersCount int
+	Conversion  float64
+}
+
+func (r LinkageReport) String() string {
+	strBuilder := strings.Builder{}
+	dateLayout := "02.01.2006"
+
+	strBuilder.WriteString(fmt.Sprintf("Конверсия по связанным заказам за период с %s по %s\n",
+		r.DateFrom.Format(dateLayout), r.DateTo.Format(dateLayout)))
+	strBuilder.WriteString(fmt.Sprintf("Заказов со звонком: %d, без звонка: %d\n", r.LinkedOrdersCount, r.UnlinkedOrdersCount))
+
+	strBuilder.WriteString(fmt.Sprintf("\n%-14s %-7s %-7s %s\n", "Город", "ун.зв.", "заказы", "конв."))
+	for _, statistic := range r.CityStatistics {
+		strBuilder.WriteString(statistic.String() + "\n")
+	}
+
+	strBuilder.WriteString(fmt.Sprintf("\n%-14s %-7s %-7s %s\n", "Линия", "ун.зв.", "заказы", "конв."))
+	for _, statistic := range r.LineStatistics {
+		strBuilder.WriteString(statistic.String() + "\n")
+	}
+
+	return strBuilder.String()
+}
+
+func (s LinkedStatistic) String() string {
+	return fmt.Sprintf("%-14s %-7d %-7d %.4g%%", s.Name, s.UniqCalls, s.OrdersCount, s.Conversion*100)
+}
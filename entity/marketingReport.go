@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AdSpend struct {
+	Source           string
+	DateFrom, DateTo time.Time
+	Amount           float64
+}
+
+type MarketingReport struct {
+	DateFrom, DateTo time.Time
+	SourceStatistics []SourceStatistic
+	//the lines matching several sources
+	AmbiguousLines []LineCalls
+}
+
+type SourceStatistic struct {
+	Source            string
+	UniqCallsTotal    int
+	UniqCallsReceived int
+	OrdersCount       int
+	Conversion        float64
+	Spend             float64
+	CostPerOrder      float64
+}
+
+func (r MarketingReport) String() string {
+	strBuilder := strings.Builder{}
+	dateLayout := "02.01.2006"
+
+	strBuilder.WriteString(fmt.Sprintf("Рекламные источники за период с %s по %s\n",
+		r.DateFrom.Format(dateLayout), r.DateTo.Format(dateLayout)))
+	strBuilder.WriteString(fmt.Sprintf("%-14s %-6s %-6s %-6s %-7s %-9s %s\n",
+		"Источник", "ун.зв.", "прин.", "заказ", "конв.", "расход", "цена з."))
+
+	for _, statistic := range r.SourceStatistics {
+		strBuilder.WriteString(statistic.String() + "\n")
+	}
+
+	if len(r.AmbiguousLines) > 0 {
+		strBuilder.WriteString("\nЛинии, подходящие нескольким источникам, звонки отнесены к первому\n")
+		for _, line := range r.AmbiguousLines {
+			strBuilder.WriteString(fmt.Sprintf("%-14s %d зв. %s\n", line.LineNumber, line.Calls, strings.Join(line.Matches, ", ")))
+		}
+	}
+
+	return strBuilder.String()
+}
+
+func (s SourceStatistic) String() string {
+	return fmt.Sprintf("%-14s %-6d %-6d %-6d %-7s %-9.0f %.0f", s.Source, s.UniqCallsTotal, s.UniqCallsReceived, s.OrdersCount,
+		fmt.Sprintf("%.4g%%", s.Conversion*100), s.Spend, s.CostPerOrder)
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	OrderCounted   = "counted"
+	OrderPending   = "pending"
+	OrderCancelled = "cancelled"
+	OrderReturned  = "returned"
+)
+
+// OrderStatuses maps the CRM order status codes to the lifecycle groups, only counted orders are paid
+type OrderStatuses map[int]string
+
+// Group returns the status' group, unmapped statuses are counted
+func (s OrderStatuses) Group(status int) string {
+	if group, ok := s[status]; ok {
+		return group
+	}
+	return OrderCounted
+}
+
+// PaidOrder is an order paid to the operator in an approved payroll, kept to claw it back if the order is cancelled later
+type PaidOrder struct {
+	Id               uint
+	Operator         string
+	Date             time.Time
+	Amount           float64
+	DateFrom, DateTo time.Time
+}
+
+// PendingOrder is an operator's order that was pending when its period was paid, it is kept in the ledger and paid once it turns counted
+type PendingOrder struct {
+	Orders
+	DateFrom, DateTo time.Time
+}
+
+// LateOrder is a pending order of an earlier period paid after it turned counted, the bonus of its period is not recounted
+type LateOrder struct {
+	PendingOrder
+	Amount float64
+}
+
+func (l LateOrder) String() string {
+	return fmt.Sprintf("заказ №%d от %s, подтвержден после периода %s - %s", l.Id, l.Date.Format("02.01.2006"),
+		l.DateFrom.Format("02.01.2006"), l.DateTo.Format("02.01.2006"))
+}
+
+// Clawback deducts a paid order cancelled or returned after its period was paid
+type Clawback struct {
+	PaidOrder
+	Status string
+}
+
+func (c Clawback) String() string {
+	status := "отменен"
+	if c.Status == OrderReturned {
+		status = "возврат"
+	}
+	return fmt.Sprintf("заказ №%d от %s (%s), оплачен за %s - %s", c.Id, c.Date.Format("02.01.2006"), status,
+		c.DateFrom.Format("02.01.2006"), c.DateTo.Format("02.01.2006"))
+}
@@ -7,4 +7,8 @@ type Orders struct {
 	Date     time.Time
 	City     string
 	Operator string
+	Phone    string
+	Status   string
+	//zero unless orders.amountColumn is set
+	Amount float64
 }
@@ -0,0 +1,13 @@
+package entity
+
+// ReportInputs are the payroll inputs otherwise asked from the admin while the report is made
+type ReportInputs struct {
+	// Expenses are asked when nil
+	Expenses []ExpenseItem
+	// PersonalBonusPerOrder is asked when nil, unless BonusFromGrade is set
+	PersonalBonusPerOrder *float64
+	// BonusFromGrade gives operators the whole department grade bonus per order
+	BonusFromGrade bool
+	// AcceptDataIssues skips the confirmation of the data check issues, they are still kept in the report
+	AcceptDataIssues bool
+}
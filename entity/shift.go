@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// Shift is an operator's scheduled presence, To is after From, night shifts end on the next day
+type Shift struct {
+	Operator string
+	From, To time.Time
+}
+
+func (s Shift) Hours() float64 {
+	return s.To.Sub(s.From).Hours()
+}
+
+func (s Shift) Contains(date time.Time) bool {
+	return !date.Before(s.From) && date.Before(s.To)
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// TalkTimeStatistic aggregates the call durations from Mango, the handle time is the talk time of an answered call
+type TalkTimeStatistic struct {
+	AnsweredCalls     int
+	TotalTalkTime     time.Duration
+	AverageHandleTime time.Duration
+	AverageWaitTime   time.Duration
+	AverageRingTime   time.Duration
+	ShortCallsShare   float64
+}
+
+// MeasuredDurations tell which call durations are read from Mango, the reports leave out the others
+type MeasuredDurations struct {
+	TalkTime bool
+	WaitTime bool
+	RingTime bool
+}
+
+// FormatDuration prints durations as minutes and seconds, hours are added for the long ones
+func FormatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
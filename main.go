@@ -1,80 +1,140 @@
 package main
 
 import (
+	"bufio"
 	"callCenterReportMaker/controller"
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/payslip"
+	"callCenterReportMaker/renderer"
 	"callCenterReportMaker/repository/database"
+	"callCenterReportMaker/repository/paidOrders"
+	"callCenterReportMaker/repository/shifts"
+	"callCenterReportMaker/scheduler"
 	"callCenterReportMaker/service"
+	"callCenterReportMaker/statImage"
 	"callCenterReportMaker/tgBot"
+	"flag"
 	"fmt"
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"io"
 	"log"
-	"regexp"
-	"strconv"
+	"os"
+	"strings"
+	"time"
 )
 
-var (
-	citiesAndLines          = make(map[string]*regexp.Regexp)
-	operators               = make([]string, 0, 7)
-	motivationMap           = make(map[float64]float64)
-	orderFee                float64
-	personalConversionGrade float64
-	dbHost                  string
-	dbPort                  string
-	dbName                  string
-	dbUser                  string
-	dbPassword              string
-	telegramToken           string
-	telegramChatId          int64
-	weekdayReportTime       string
-	weekendReportTime       string
+var cfg config
+
+const (
+	configDateLayout    = "02.01.2006"
+	defaultHolidaysPath = "data/holidays.yaml"
 )
 
 func init() {
-	viper.SetConfigFile("config.yaml")
-	err := viper.ReadInConfig()
-	if err != nil {
-		panic(fmt.Errorf("fatal error config file: %w", err))
+	if err := initConfig(); err != nil {
+		log.Fatal(fmt.Errorf("fatal error config file: %w", err))
+	}
+	var err error
+	if cfg, err = loadConfig(); err != nil {
+		log.Fatal(fmt.Errorf("%s:\n%w", configPath, err))
+	}
+}
+
+func main() {
+	cliDateFrom := flag.String("from", "", "build the weekly report from the console starting from this date (DD.MM.YYYY) instead of running the bot")
+	cliDateTo := flag.String("to", "", "last date of the console report (DD.MM.YYYY)")
+	cliFormats := flag.String("format", strings.Join(cfg.reportFormats, ","), "comma separated console report formats")
+	cliOutput := flag.String("out", "report", "console report path without extension")
+	cliExpenses := flag.String("expenses", "", "all expenses of the console report as \"name=amount;name=amount\", asked interactively when empty")
+	cliAcceptDataIssues := flag.Bool("accept-data-issues", false, "compute the console report without confirming the data check issues")
+	flag.Parse()
+
+	db := database.New(cfg.dbHost, cfg.dbPort, cfg.dbName, cfg.dbUser, cfg.dbPassword, cfg.operators, cfg.businessLocation, cfg.durationColumns, cfg.orderStatuses, cfg.orderColumns)
+	ctrl := controller.New(newService(cfg), db, shifts.New(cfg.shiftsPath, cfg.operators, cfg.businessLocation),
+		paidOrders.New(cfg.paidOrdersPath, cfg.pendingOrdersPath, cfg.businessLocation), statImage.New(cfg.imagesFontPath), cfg.reportTemplates, cfg.businessLocation, cfg.calendar)
+
+	if *cliDateFrom != "" {
+		makeConsoleReport(ctrl, *cliDateFrom, *cliDateTo, *cliExpenses, *cliAcceptDataIssues, strings.Split(*cliFormats, ","), *cliOutput)
+		return
 	}
 
-	orderFee = viper.GetFloat64("salary.orderFee")
-	personalConversionGrade = viper.GetFloat64("salary.personalConversionGrade")
-	operators = viper.GetStringSlice("salary.operators")
-	dbHost = viper.GetString("database.host")
-	dbPort = viper.GetString("database.port")
-	dbName = viper.GetString("database.database")
-	dbUser = viper.GetString("database.user")
-	dbPassword = viper.GetString("database.password")
-	telegramToken = viper.GetString("telegram.token")
-	telegramChatId = viper.GetInt64("telegram.chatId")
-	weekdayReportTime = viper.GetString("report.weekdayReportTime")
-	weekendReportTime = viper.GetString("report.weekendReportTime")
-
-	for city, rExp := range viper.GetStringMapString("citiesAndLinesRegexpMap") {
-		citiesAndLines[cases.Title(language.Russian).String(city)] = regexp.MustCompile(rExp)
+	jobs, err := scheduler.New(cfg.schedulerJobs, cfg.schedulerLocation, cfg.schedulerStatusPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	for gradeStr, bonusStr := range viper.GetStringMapString("salary.motivationMap") {
-		grade, err := strconv.ParseFloat(gradeStr, 64)
+	bot := tgBot.New(ctrl, cfg.telegramToken, cfg.telegramChatId, jobs, cfg.reportFormats, cfg.reportTemplates, payslip.New(cfg.payslipFontPath), cfg.operatorChats, cfg.imageChats, cfg.businessLocation, cfg.calendar)
+
+	watchConfig(ctrl)
+
+	go bot.StartBot()
+
+	<-make(chan error)
+}
+
+func newService(cfg config) service.Service {
+	return service.New(cfg.cityLines, cfg.operators, cfg.motivationMap, cfg.orderFee, cfg.personalConversionGrade, cfg.uniquenessPolicy, cfg.sourceLines, cfg.adSpends, cfg.linkageWindowDays, cfg.anomalySettings, cfg.recurringExpenses, cfg.workCalendar, cfg.holidayFees, cfg.talkTimeSettings, cfg.commissionTiers)
+}
+
+// watchConfig swaps the operators, cities and motivation map when config.yaml changes, a config with problems is
+// skipped and the other settings wait for a restart
+func watchConfig(ctrl controller.Controller) {
+	viper.OnConfigChange(func(event fsnotify.Event) {
+		reloaded, err := loadConfig()
 		if err != nil {
-			log.Fatal(err)
+			log.Println(fmt.Errorf("%s is not reloaded:\n%w", configPath, err))
+			return
 		}
-		bonus, err := strconv.ParseFloat(bonusStr, 64)
-		if err != nil {
+		next := cfg
+		next.operators, next.cityLines, next.motivationMap = reloaded.operators, reloaded.cityLines, reloaded.motivationMap
+		ctrl.Reload(newService(next), next.operators)
+		log.Printf("%s reloaded: %d operators, %d cities", configPath, len(next.operators), len(next.cityLines))
+	})
+	viper.WatchConfig()
+}
+
+func makeConsoleReport(ctrl controller.Controller, dateFromStr, dateToStr, expensesStr string, acceptDataIssues bool, formats []string, output string) {
+	dateFrom, err := time.ParseInLocation(configDateLayout, dateFromStr, cfg.businessLocation)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dateTo, err := time.ParseInLocation(configDateLayout, dateToStr, cfg.businessLocation)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inputs := entity.ReportInputs{AcceptDataIssues: acceptDataIssues}
+	if expensesStr != "" {
+		if inputs.Expenses, err = entity.ParseExpenses(expensesStr); err != nil {
 			log.Fatal(err)
 		}
-		motivationMap[grade] = bonus
 	}
+
+	report, err := ctrl.MakeReport(dateFrom, dateTo, inputs, console{bufio.NewScanner(os.Stdin)})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files, err := renderer.RenderAll(report, output, formats, cfg.reportTemplates)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(strings.Join(files, "\n"))
 }
 
-func main() {
-	srv := service.New(citiesAndLines, operators, motivationMap, orderFee, personalConversionGrade)
-	db := database.New(dbHost, dbPort, dbName, dbUser, dbPassword, operators)
-	ctrl := controller.New(srv, db)
-	bot := tgBot.New(ctrl, telegramToken, telegramChatId, weekdayReportTime, weekendReportTime)
+// console answers every Read with a single line, the same way the bot answers with a single message
+type console struct {
+	scanner *bufio.Scanner
+}
 
-	go bot.StartBot()
+func (c console) Read(b []byte) (int, error) {
+	if !c.scanner.Scan() {
+		return 0, io.EOF
+	}
+	return copy(b, c.scanner.Text()), io.EOF
+}
 
-	<-make(chan error)
+func (c console) Write(b []byte) (int, error) {
+	return os.Stdout.Write(b)
 }
@@ -0,0 +1,112 @@
+package payslip
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"os"
+)
+
+const (
+	fontFamily = "payslip"
+	dateLayout = "02.01.2006"
+)
+
+type Generator interface {
+	Generate(report entity.WeeklyReport, operatorReport entity.OperatorReport, path string) error
+}
+
+type generator struct {
+	fontPath string
+}
+
+// New expects a TrueType font with cyrillic glyphs, e.g. DejaVuSans.ttf
+func New(fontPath string) Generator {
+	return generator{fontPath: fontPath}
+}
+
+func (g generator) Generate(report entity.WeeklyReport, operatorReport entity.OperatorReport, path string) error {
+	font, err := os.ReadFile(g.fontPath)
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", font)
+	pdf.AddPage()
+
+	pdf.SetFont(fontFamily, "", 18)
+	pdf.CellFormat(0, 12, "Расчетный лист", "", 1, "C", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 12)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Период: %s - %s",
+		report.DateFrom.Format(dateLayout), report.DateTo.Format(dateLayout)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 8, "Сотрудник: "+operatorReport.Name, "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	rows := [][2]string{{"Принято заказов", fmt.Sprintf("%d", operatorReport.OrdersCount)}}
+	if operatorReport.CommissionRate > 0 {
+		rows = append(rows,
+			[2]string{"Сумма заказов", formatMoney(operatorReport.Revenue)},
+			[2]string{"Комиссия от суммы заказов", fmt.Sprintf("%.2f%%", operatorReport.CommissionRate*100)},
+		)
+	} else {
+		rows = append(rows, [2]string{"Ставка за заказ", formatMoney(operatorReport.OrderFee)})
+	}
+	rows = append(rows, [2]string{"Оплата за заказы", formatMoney(operatorReport.Salary)})
+	if operatorReport.HolidayPay > 0 {
+		rows = append(rows, [2]string{"в т.ч. доплата за праздники", formatMoney(operatorReport.HolidayPay)})
+	}
+	for _, order := range report.LateOrders {
+		if order.Operator == operatorReport.Name {
+			rows = append(rows, [2]string{fmt.Sprintf("в т.ч. подтвержденный заказ №%d от %s", order.Id, order.Date.Format(dateLayout)),
+				formatMoney(order.Amount)})
+		}
+	}
+	if operatorReport.UniqCalls > 0 {
+		gradeStatus := "не выполнен"
+		if operatorReport.Conversion > operatorReport.ConversionGrade {
+			gradeStatus = "выполнен"
+		}
+		rows = append(rows,
+			[2]string{"Уникальных звонков", fmt.Sprintf("%d", operatorReport.UniqCalls)},
+			[2]string{"Конверсия", fmt.Sprintf("%.2f%%", operatorReport.Conversion*100)},
+			[2]string{"Порог конверсии для премии", fmt.Sprintf("%.2f%% (%s)", operatorReport.ConversionGrade*100, gradeStatus)},
+		)
+	}
+	if report.MeasuredDurations.TalkTime && operatorReport.TalkTime.AnsweredCalls > 0 {
+		rows = append(rows,
+			[2]string{"Среднее время разговора", entity.FormatDuration(operatorReport.TalkTime.AverageHandleTime)},
+			[2]string{"Доля коротких звонков", fmt.Sprintf("%.2f%%", operatorReport.TalkTime.ShortCallsShare*100)},
+		)
+	}
+	if operatorReport.HoursWorked > 0 {
+		rows = append(rows,
+			[2]string{"Часов в смене по графику", fmt.Sprintf("%.1f", operatorReport.HoursWorked)},
+			[2]string{"Заказов в час", fmt.Sprintf("%.2f", operatorReport.OrdersPerHour)},
+		)
+	}
+	rows = append(rows, [2]string{"Премия", formatMoney(operatorReport.Bonus)})
+	for _, clawback := range report.Clawbacks {
+		if clawback.Operator == operatorReport.Name {
+			rows = append(rows, [2]string{fmt.Sprintf("Удержание, заказ №%d от %s", clawback.Id, clawback.Date.Format(dateLayout)),
+				"-" + formatMoney(clawback.Amount)})
+		}
+	}
+
+	for _, row := range rows {
+		pdf.CellFormat(110, 9, row[0], "1", 0, "L", false, 0, "")
+		pdf.CellFormat(70, 9, row[1], "1", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont(fontFamily, "", 14)
+	pdf.SetFillColor(0x92, 0xD0, 0x50)
+	pdf.CellFormat(110, 11, "Итого к выплате", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(70, 11, formatMoney(operatorReport.SummaryPayment), "1", 1, "R", true, 0, "")
+
+	return pdf.OutputFileAndClose(path)
+}
+
+func formatMoney(value float64) string {
+	return fmt.Sprintf("%.2f руб.", value)
+}
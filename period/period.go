@@ -0,0 +1,143 @@
+package period
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	IsoWeek  = "isoWeek"
+	Week     = "week"
+	Biweekly = "biweekly"
+	Month    = "month"
+
+	dateLayout = "02.01.2006"
+	weekDays   = 7
+)
+
+// Period is a range of whole days, To is included until its end
+type Period struct {
+	From, To time.Time
+}
+
+func (p Period) Days() int {
+	return daysBetween(p.From, p.To) + 1
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s - %s", p.From.Format(dateLayout), p.To.Format(dateLayout))
+}
+
+// Definition describes repeating periods: ISO weeks, weeks starting on WeekStart,
+// two week pay periods counted from Anchor or calendar months
+type Definition struct {
+	Type      string
+	WeekStart time.Weekday
+	Anchor    time.Time
+}
+
+func (d Definition) Validate() error {
+	switch d.Type {
+	case IsoWeek, Week, Month:
+		return nil
+	case Biweekly:
+		if d.Anchor.IsZero() {
+			return fmt.Errorf("для периода %s нужна дата начала любого из периодов", Biweekly)
+		}
+		return nil
+	default:
+		return fmt.Errorf("неизвестный тип периода %q, доступны: %s, %s, %s, %s", d.Type, IsoWeek, Week, Biweekly, Month)
+	}
+}
+
+// Containing returns the period the day belongs to
+func (d Definition) Containing(day time.Time) Period {
+	day = truncateToDay(day)
+
+	switch d.Type {
+	case Week:
+		from := day.AddDate(0, 0, -((int(day.Weekday()) - int(d.WeekStart) + weekDays) % weekDays))
+		return Period{From: from, To: from.AddDate(0, 0, weekDays-1)}
+	case Biweekly:
+		anchor := truncateToDay(d.Anchor.In(day.Location()))
+		passed := int(math.Floor(float64(daysBetween(anchor, day)) / (2 * weekDays)))
+		from := anchor.AddDate(0, 0, passed*2*weekDays)
+		return Period{From: from, To: from.AddDate(0, 0, 2*weekDays-1)}
+	case Month:
+		from := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return Period{From: from, To: from.AddDate(0, 1, -1)}
+	default:
+		return Definition{Type: Week, WeekStart: time.Monday}.Containing(day)
+	}
+}
+
+// Previous returns the period before the one the day belongs to
+func (d Definition) Previous(day time.Time) Period {
+	return d.Containing(d.Containing(day).From.AddDate(0, 0, -1))
+}
+
+// Calendar holds the period definitions shared by the statistics, the payroll report and the commands
+type Calendar struct {
+	Statistics Definition
+	Payroll    Definition
+	Named      map[string]Period
+}
+
+// Resolve turns a phrase like "прошлая неделя" or a named period into a period, relative to today
+func (c Calendar) Resolve(phrase string, today time.Time) (Period, bool) {
+	phrase = strings.ToLower(strings.Join(strings.Fields(phrase), " "))
+	week := c.week()
+	month := Definition{Type: Month}
+
+	switch phrase {
+	case "эта неделя", "текущая неделя":
+		return week.Containing(today), true
+	case "прошлая неделя":
+		return week.Previous(today), true
+	case "этот месяц", "текущий месяц":
+		return month.Containing(today), true
+	case "прошлый месяц":
+		return month.Previous(today), true
+	case "этот период", "текущий период":
+		return c.Payroll.Containing(today), true
+	case "прошлый период":
+		return c.Payroll.Previous(today), true
+	}
+
+	for name, period := range c.Named {
+		if strings.ToLower(name) == phrase {
+			return period, true
+		}
+	}
+	return Period{}, false
+}
+
+// week is the statistics week, or a week starting on the statistics week start when the statistics use longer periods
+func (c Calendar) week() Definition {
+	if c.Statistics.Type == IsoWeek {
+		return c.Statistics
+	}
+	return Definition{Type: Week, WeekStart: c.Statistics.WeekStart}
+}
+
+// ParseWeekday reads english week day names used in the config
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("неизвестный день недели %q", name)
+}
+
+func truncateToDay(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+}
+
+// daysBetween rounds the hours, days around DST changes are not 24 hours long
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,97 @@
+package period
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDefinitionContainingAndPrevious(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, berlin)
+	}
+	week := Definition{Type: Week, WeekStart: time.Monday}
+	saturdayWeek := Definition{Type: Week, WeekStart: time.Saturday}
+	biweekly := Definition{Type: Biweekly, Anchor: date(2024, time.January, 1)}
+	month := Definition{Type: Month}
+
+	tests := []struct {
+		name       string
+		definition Definition
+		day        time.Time
+		containing Period
+		previous   Period
+	}{
+		{"last minute of the week", week, time.Date(2024, time.March, 31, 23, 59, 0, 0, berlin),
+			Period{date(2024, time.March, 25), date(2024, time.March, 31)}, Period{date(2024, time.March, 18), date(2024, time.March, 24)}},
+		{"first day of the week", week, date(2024, time.April, 1),
+			Period{date(2024, time.April, 1), date(2024, time.April, 7)}, Period{date(2024, time.March, 25), date(2024, time.March, 31)}},
+		{"week across the autumn DST change", week, time.Date(2024, time.October, 27, 23, 30, 0, 0, berlin),
+			Period{date(2024, time.October, 21), date(2024, time.October, 27)}, Period{date(2024, time.October, 14), date(2024, time.October, 20)}},
+		{"week starting on saturday", saturdayWeek, date(2024, time.March, 29),
+			Period{date(2024, time.March, 23), date(2024, time.March, 29)}, Period{date(2024, time.March, 16), date(2024, time.March, 22)}},
+		{"last day of the pay period", biweekly, time.Date(2024, time.January, 14, 23, 59, 0, 0, berlin),
+			Period{date(2024, time.January, 1), date(2024, time.January, 14)}, Period{date(2023, time.December, 18), date(2023, time.December, 31)}},
+		{"first day of the pay period", biweekly, date(2024, time.January, 15),
+			Period{date(2024, time.January, 15), date(2024, time.January, 28)}, Period{date(2024, time.January, 1), date(2024, time.January, 14)}},
+		{"pay period across the spring DST change", biweekly, time.Date(2024, time.March, 31, 23, 30, 0, 0, berlin),
+			Period{date(2024, time.March, 25), date(2024, time.April, 7)}, Period{date(2024, time.March, 11), date(2024, time.March, 24)}},
+		{"first day after the pay period with the DST change", biweekly, date(2024, time.April, 8),
+			Period{date(2024, time.April, 8), date(2024, time.April, 21)}, Period{date(2024, time.March, 25), date(2024, time.April, 7)}},
+		{"leap day", month, time.Date(2024, time.February, 29, 23, 59, 0, 0, berlin),
+			Period{date(2024, time.February, 1), date(2024, time.February, 29)}, Period{date(2024, time.January, 1), date(2024, time.January, 31)}},
+		{"last minute of the month", month, time.Date(2024, time.March, 31, 23, 59, 0, 0, berlin),
+			Period{date(2024, time.March, 1), date(2024, time.March, 31)}, Period{date(2024, time.February, 1), date(2024, time.February, 29)}},
+		{"first day of the year", month, date(2024, time.January, 1),
+			Period{date(2024, time.January, 1), date(2024, time.January, 31)}, Period{date(2023, time.December, 1), date(2023, time.December, 31)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.definition.Containing(test.day); !samePeriod(got, test.containing) {
+				t.Errorf("Containing(%s) = %s, want %s", test.day, got, test.containing)
+			}
+			if got := test.definition.Previous(test.day); !samePeriod(got, test.previous) {
+				t.Errorf("Previous(%s) = %s, want %s", test.day, got, test.previous)
+			}
+		})
+	}
+}
+
+func samePeriod(a, b Period) bool {
+	return a.From.Equal(b.From) && a.To.Equal(b.To)
+}
+
+func TestCalendarResolveWeek(t *testing.T) {
+	wednesday := time.Date(2024, time.March, 27, 0, 0, 0, 0, time.UTC)
+	date := func(day int) time.Time {
+		return time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		statistics Definition
+		this, last Period
+	}{
+		{"iso weeks", Definition{Type: IsoWeek, WeekStart: time.Monday}, Period{date(25), date(31)}, Period{date(18), date(24)}},
+		{"weeks from saturday", Definition{Type: Week, WeekStart: time.Saturday}, Period{date(23), date(29)}, Period{date(16), date(22)}},
+		{"monthly statistics", Definition{Type: Month, WeekStart: time.Monday}, Period{date(25), date(31)}, Period{date(18), date(24)}},
+		{"pay periods", Definition{Type: Biweekly, WeekStart: time.Sunday, Anchor: date(4)}, Period{date(24), date(30)}, Period{date(17), date(23)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calendar := Calendar{Statistics: test.statistics}
+			if got, _ := calendar.Resolve("эта неделя", wednesday); !samePeriod(got, test.this) {
+				t.Errorf("эта неделя = %s, want %s", got, test.this)
+			}
+			if got, _ := calendar.Resolve("прошлая неделя", wednesday); !samePeriod(got, test.last) {
+				t.Errorf("прошлая неделя = %s, want %s", got, test.last)
+			}
+		})
+	}
+}
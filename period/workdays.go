@@ -0,0 +1,113 @@
+package period
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+const (
+	Holiday    = "holiday"
+	ShortDay   = "short"
+	WorkingDay = "workday"
+
+	dayKeyLayout = "2006-01-02"
+)
+
+// Day is an entry of the working-day calendar, a WorkingDay entry cancels a holiday or a short day loaded before it
+type Day struct {
+	Date time.Time
+	Kind string
+	Name string
+}
+
+// Label is the day's mark in the reports, empty for ordinary days
+func (d Day) Label() string {
+	switch {
+	case d.Kind == Holiday && d.Name != "":
+		return d.Name
+	case d.Kind == Holiday:
+		return "праздник"
+	case d.Kind == ShortDay && d.Name != "":
+		return d.Name + ", сокращенный день"
+	case d.Kind == ShortDay:
+		return "сокращенный день"
+	default:
+		return ""
+	}
+}
+
+// WorkCalendar marks public holidays and short days, the days it doesn't know are ordinary ones
+type WorkCalendar struct {
+	days map[string]Day
+}
+
+type dayRecord struct {
+	Date string `yaml:"date"`
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+}
+
+// LoadWorkCalendar reads a yaml list of {date: DD.MM.YYYY, kind: holiday|short|workday, name}
+func LoadWorkCalendar(path string, location *time.Location) (WorkCalendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return WorkCalendar{}, err
+	}
+
+	var records []dayRecord
+	if err = yaml.Unmarshal(data, &records); err != nil {
+		return WorkCalendar{}, fmt.Errorf("%s: %w", path, err)
+	}
+	days := make([]Day, 0, len(records))
+	for _, record := range records {
+		day, err := ParseDay(record.Date, record.Kind, record.Name, location)
+		if err != nil {
+			return WorkCalendar{}, fmt.Errorf("%s: %w", path, err)
+		}
+		days = append(days, day)
+	}
+	return WorkCalendar{}.With(days), nil
+}
+
+func ParseDay(date, kind, name string, location *time.Location) (Day, error) {
+	parsed, err := time.ParseInLocation(dateLayout, date, location)
+	if err != nil {
+		return Day{}, err
+	}
+	switch kind {
+	case Holiday, ShortDay, WorkingDay:
+	default:
+		return Day{}, fmt.Errorf("%s: неизвестный тип дня %q, доступны: %s, %s, %s", date, kind, Holiday, ShortDay, WorkingDay)
+	}
+	return Day{Date: parsed, Kind: kind, Name: name}, nil
+}
+
+// With returns a copy of the calendar with the days added over the known ones
+func (c WorkCalendar) With(days []Day) WorkCalendar {
+	result := WorkCalendar{days: make(map[string]Day, len(c.days)+len(days))}
+	for key, day := range c.days {
+		result.days[key] = day
+	}
+	for _, day := range days {
+		key := day.Date.Format(dayKeyLayout)
+		if day.Kind == WorkingDay {
+			delete(result.days, key)
+			continue
+		}
+		result.days[key] = day
+	}
+	return result
+}
+
+// Day returns the calendar entry of the date, the ok is false for ordinary days
+func (c WorkCalendar) Day(date time.Time) (Day, bool) {
+	day, ok := c.days[date.Format(dayKeyLayout)]
+	return day, ok
+}
+
+func (c WorkCalendar) IsHoliday(date time.Time) bool {
+	day, ok := c.Day(date)
+	return ok && day.Kind == Holiday
+}
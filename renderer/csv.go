@@ -0,0 +1,202 @@
+package renderer
+
+import (
+	"callCenterReportMaker/entity"
+	"encoding/csv"
+	"os"
+	"strconv"
+)
+
+const csvComma = ';'
+
+type csvRenderer struct{}
+
+func (c csvRenderer) Render(r entity.WeeklyReport, basePath string) ([]string, error) {
+	unmeasured := unmeasuredKeys(r.MeasuredDurations)
+	sections := []struct {
+		suffix string
+		rows   [][]string
+	}{
+		{"_operators", withoutColumns(c.operatorRows(r), operatorColumnKeys, unmeasured)},
+		{"_expenses", c.expenseRows(r)},
+		{"_cities", withoutColumns(c.cityRows("Город", r.CityStatistics), cityRowKeys, unmeasured)},
+		{"_summary", c.summaryRows(r)},
+		{"_daily", c.dailyRows(r)},
+		{"_clawbacks", c.clawbackRows(r)},
+		{"_late_orders", c.lateOrderRows(r)},
+	}
+	if len(r.RegionStatistics) > 0 {
+		sections = append(sections, struct {
+			suffix string
+			rows   [][]string
+		}{"_regions", withoutColumns(c.cityRows("Регион", r.RegionStatistics), cityRowKeys, unmeasured)})
+	}
+
+	files := make([]string, 0, len(sections))
+	for _, section := range sections {
+		path := basePath + section.suffix + ".csv"
+		if err := c.writeFile(path, section.rows); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+func (c csvRenderer) writeFile(path string, rows [][]string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}(file)
+
+	writer := csv.NewWriter(file)
+	writer.Comma = csvComma
+	return writer.WriteAll(rows)
+}
+
+func (c csvRenderer) operatorRows(r entity.WeeklyReport) [][]string {
+	rows := [][]string{{"ФИО", "ЗП", "Премия", "Удержания", "ЗП + Премия", "Принято заказов", "Цена за заказ", "ун. зв.", "конв.",
+		"Часов в смене", "Заказов в час", "Звонков в час", "Конв. в смене", "Звонков вне смены",
+		"Ср. время разговора", "Время разговоров", "Доля коротких", "Выручка", "Средний чек"}}
+	for _, report := range r.OperatorReports {
+		rows = append(rows, []string{
+			report.Name,
+			formatMoney(report.Salary),
+			formatMoney(report.Bonus),
+			formatMoney(report.Clawback),
+			formatMoney(report.SummaryPayment),
+			strconv.Itoa(report.OrdersCount),
+			formatMoney(report.PricePerOrder),
+			strconv.Itoa(report.UniqCalls),
+			formatRatio(report.Conversion),
+			formatRatio(report.HoursWorked),
+			formatRatio(report.OrdersPerHour),
+			formatRatio(report.CallsPerHour),
+			formatRatio(report.ShiftConversion),
+			strconv.Itoa(report.OffShiftCalls),
+			entity.FormatDuration(report.TalkTime.AverageHandleTime),
+			entity.FormatDuration(report.TalkTime.TotalTalkTime),
+			formatRatio(report.TalkTime.ShortCallsShare),
+			formatMoney(report.Revenue),
+			formatMoney(report.AverageOrderValue),
+		})
+	}
+	return rows
+}
+
+func (c csvRenderer) expenseRows(r entity.WeeklyReport) [][]string {
+	rows := [][]string{
+		{"Статья", "Сумма", "Цена за заказ"},
+		{"Цена заказа по операторам", formatMoney(r.DepartmentPayment), formatMoney(r.DepartmentPricePerOrder)},
+	}
+	for _, expense := range r.Expenses {
+		rows = append(rows, []string{expense.Name, formatMoney(expense.Amount), ""})
+	}
+	return append(rows, []string{"Итого", formatMoney(r.TotalExpenses), formatMoney(r.TotalPricePerOrder)})
+}
+
+func (c csvRenderer) cityRows(title string, statistics []entity.CityStatistic) [][]string {
+	rows := [][]string{{title, "Звонков уникальных всего", "Звонков уникальных успешных", "Звонков уникальных пропущено", "Заказов принято", "Заказов по звонкам", "Конверсия",
+		"Ср. время разговора", "Ср. ожидание", "Ср. дозвон", "Доля коротких", "Выручка", "Средний чек"}}
+	for _, city := range statistics {
+		rows = append(rows, []string{
+			city.City,
+			strconv.Itoa(city.UniqCallsTotal),
+			strconv.Itoa(city.UniqCallsReceived),
+			strconv.Itoa(city.UniqCallsMissed),
+			strconv.Itoa(city.OrdersCount),
+			strconv.Itoa(city.LinkedOrdersCount),
+			formatRatio(city.Conversion),
+			entity.FormatDuration(city.TalkTime.AverageHandleTime),
+			entity.FormatDuration(city.TalkTime.AverageWaitTime),
+			entity.FormatDuration(city.TalkTime.AverageRingTime),
+			formatRatio(city.TalkTime.ShortCallsShare),
+			formatMoney(city.Revenue),
+			formatMoney(city.AverageOrderValue),
+		})
+	}
+	return rows
+}
+
+func (c csvRenderer) summaryRows(r entity.WeeklyReport) [][]string {
+	return [][]string{
+		{"Статья", "Сумма"},
+		{"ЗП операторы, общая сумма", formatMoney(r.SummaryDepartmentSalary)},
+		{"Премия операторы, общая сумма", formatMoney(r.SummaryDepartmentBonus)},
+		{"Удержания за отмененные заказы", formatMoney(r.SummaryClawback)},
+		{"Итого за неделю", formatMoney(r.SumToPay)},
+		{"Выручка, общая сумма", formatMoney(r.TotalRevenue)},
+		{"Средний чек", formatMoney(r.AverageOrderValue)},
+		{"Доля расходов в выручке", formatRatio(r.ExpensesToRevenue)},
+	}
+}
+
+func (c csvRenderer) dailyRows(r entity.WeeklyReport) [][]string {
+	rows := [][]string{{"Дата", "ун. зв.", "Заказов принято", "Конверсия", "Праздник"}}
+	for _, statistic := range r.DailyStatistics {
+		rows = append(rows, []string{
+			statistic.Date.Format("02.01.2006"),
+			strconv.Itoa(statistic.UniqCalls),
+			strconv.Itoa(statistic.OrdersCount),
+			formatRatio(statistic.Conversion),
+			statistic.Holiday,
+		})
+	}
+	return rows
+}
+
+func (c csvRenderer) clawbackRows(r entity.WeeklyReport) [][]string {
+	rows := [][]string{{"ФИО", "Заказ", "Дата заказа", "Статус", "Оплачен за период", "Сумма"}}
+	for _, clawback := range r.Clawbacks {
+		rows = append(rows, []string{
+			clawback.Operator,
+			strconv.FormatUint(uint64(clawback.Id), 10),
+			clawback.Date.Format("02.01.2006"),
+			clawback.Status,
+			clawback.DateFrom.Format("02.01.2006") + " - " + clawback.DateTo.Format("02.01.2006"),
+			formatMoney(clawback.Amount),
+		})
+	}
+	return rows
+}
+
+// withoutColumns drops the columns with the given keys, the keys name the columns in order
+func withoutColumns(rows [][]string, keys []string, drop map[string]bool) [][]string {
+	for i, row := range rows {
+		kept := make([]string, 0, len(row))
+		for j, value := range row {
+			if !drop[keys[j]] {
+				kept = append(kept, value)
+			}
+		}
+		rows[i] = kept
+	}
+	return rows
+}
+
+func (c csvRenderer) lateOrderRows(r entity.WeeklyReport) [][]string {
+	rows := [][]string{{"ФИО", "Заказ", "Дата заказа", "Ожидал подтверждения с периода", "Сумма"}}
+	for _, order := range r.LateOrders {
+		rows = append(rows, []string{
+			order.Operator,
+			strconv.FormatUint(uint64(order.Id), 10),
+			order.Date.Format("02.01.2006"),
+			order.DateFrom.Format("02.01.2006") + " - " + order.DateTo.Format("02.01.2006"),
+			formatMoney(order.Amount),
+		})
+	}
+	return rows
+}
+
+func formatMoney(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func formatRatio(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
@@ -0,0 +1,102 @@
+package renderer
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"html/template"
+	"os"
+	"slices"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"money":    func(value float64) string { return fmt.Sprintf("%.2f ₽", value) },
+	"percent":  func(value float64) string { return fmt.Sprintf("%.2f%%", value*100) },
+	"duration": entity.FormatDuration,
+	"date": func(r entity.WeeklyReport) string {
+		return r.DateFrom.Format("02.01.2006") + " - " + r.DateTo.Format("02.01.2006")
+	},
+	"measured": func(r entity.WeeklyReport, key string) bool {
+		return !unmeasuredKeys(r.MeasuredDurations)[key]
+	},
+	//the expense rows span the operator columns, the unmeasured ones are left out
+	"colspan": func(r entity.WeeklyReport, span int) int {
+		for key := range unmeasuredKeys(r.MeasuredDurations) {
+			if slices.Contains(operatorColumnKeys, key) {
+				span--
+			}
+		}
+		return span
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Отчет {{date .}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.department { background: #FFFF00; }
+.total { background: #92D050; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Отчет {{date .}}</h1>
+<table>
+<tr><th>ФИО</th><th>ЗП</th><th>Премия</th><th>Удержания</th><th>ЗП + Премия</th><th>Принято заказов</th><th>Цена за заказ</th><th>ун. зв.</th><th>конв.</th><th>Часов в смене</th><th>Заказов в час</th><th>Звонков в час</th><th>Конв. в смене</th><th>Звонков вне смены</th>{{if measured $ "averageHandleTime"}}<th>Ср. время разговора</th>{{end}}{{if measured $ "totalTalkTime"}}<th>Время разговоров</th>{{end}}{{if measured $ "shortCallsShare"}}<th>Доля коротких</th>{{end}}<th>Выручка</th><th>Средний чек</th></tr>
+{{range .OperatorReports}}<tr><td>{{.Name}}</td><td>{{money .Salary}}</td><td>{{money .Bonus}}</td><td>{{money .Clawback}}</td><td>{{money .SummaryPayment}}</td><td>{{.OrdersCount}}</td><td>{{money .PricePerOrder}}</td><td>{{.UniqCalls}}</td><td>{{percent .Conversion}}</td><td>{{printf "%.1f" .HoursWorked}}</td><td>{{printf "%.2f" .OrdersPerHour}}</td><td>{{printf "%.2f" .CallsPerHour}}</td><td>{{percent .ShiftConversion}}</td><td>{{.OffShiftCalls}}</td>{{if measured $ "averageHandleTime"}}<td>{{duration .TalkTime.AverageHandleTime}}</td>{{end}}{{if measured $ "totalTalkTime"}}<td>{{duration .TalkTime.TotalTalkTime}}</td>{{end}}{{if measured $ "shortCallsShare"}}<td>{{percent .TalkTime.ShortCallsShare}}</td>{{end}}<td>{{money .Revenue}}</td><td>{{money .AverageOrderValue}}</td></tr>
+{{end}}<tr class="department"><td>Цена заказа по операторам</td><td>{{money .DepartmentPayment}}</td><td></td><td></td><td></td><td></td><td>{{money .DepartmentPricePerOrder}}</td><td colspan="{{colspan $ 12}}"></td></tr>
+{{range .Expenses}}<tr><td>{{.Name}}</td><td>{{money .Amount}}</td><td colspan="{{colspan $ 17}}"></td></tr>
+{{end}}<tr class="total"><td>Итого</td><td>{{money .TotalExpenses}}</td><td></td><td></td><td></td><td>{{.TotalOrdersCount}}</td><td>{{money .TotalPricePerOrder}}</td><td colspan="{{colspan $ 12}}"></td></tr>
+</table>
+<table>
+<tr><th>Город</th><th>Звонков уникальных всего</th><th>Звонков уникальных успешных</th><th>Звонков уникальных пропущено</th><th>Заказов принято</th><th>Заказов по звонкам</th><th>Конверсия</th>{{if measured $ "averageHandleTime"}}<th>Ср. время разговора</th>{{end}}{{if measured $ "averageWaitTime"}}<th>Ср. ожидание</th>{{end}}{{if measured $ "averageRingTime"}}<th>Ср. дозвон</th>{{end}}{{if measured $ "shortCallsShare"}}<th>Доля коротких</th>{{end}}<th>Выручка</th><th>Средний чек</th></tr>
+{{range .CityStatistics}}<tr><td>{{.City}}</td><td>{{.UniqCallsTotal}}</td><td>{{.UniqCallsReceived}}</td><td>{{.UniqCallsMissed}}</td><td>{{.OrdersCount}}</td><td>{{.LinkedOrdersCount}}</td><td>{{percent .Conversion}}</td>{{if measured $ "averageHandleTime"}}<td>{{duration .TalkTime.AverageHandleTime}}</td>{{end}}{{if measured $ "averageWaitTime"}}<td>{{duration .TalkTime.AverageWaitTime}}</td>{{end}}{{if measured $ "averageRingTime"}}<td>{{duration .TalkTime.AverageRingTime}}</td>{{end}}{{if measured $ "shortCallsShare"}}<td>{{percent .TalkTime.ShortCallsShare}}</td>{{end}}<td>{{money .Revenue}}</td><td>{{money .AverageOrderValue}}</td></tr>
+{{end}}</table>
+{{if .RegionStatistics}}<table>
+<tr><th>Регион</th><th>Звонков уникальных всего</th><th>Звонков уникальных успешных</th><th>Звонков уникальных пропущено</th><th>Заказов принято</th><th>Заказов по звонкам</th><th>Конверсия</th>{{if measured $ "averageHandleTime"}}<th>Ср. время разговора</th>{{end}}{{if measured $ "averageWaitTime"}}<th>Ср. ожидание</th>{{end}}{{if measured $ "averageRingTime"}}<th>Ср. дозвон</th>{{end}}{{if measured $ "shortCallsShare"}}<th>Доля коротких</th>{{end}}<th>Выручка</th><th>Средний чек</th></tr>
+{{range .RegionStatistics}}<tr><td>{{.City}}</td><td>{{.UniqCallsTotal}}</td><td>{{.UniqCallsReceived}}</td><td>{{.UniqCallsMissed}}</td><td>{{.OrdersCount}}</td><td>{{.LinkedOrdersCount}}</td><td>{{percent .Conversion}}</td>{{if measured $ "averageHandleTime"}}<td>{{duration .TalkTime.AverageHandleTime}}</td>{{end}}{{if measured $ "averageWaitTime"}}<td>{{duration .TalkTime.AverageWaitTime}}</td>{{end}}{{if measured $ "averageRingTime"}}<td>{{duration .TalkTime.AverageRingTime}}</td>{{end}}{{if measured $ "shortCallsShare"}}<td>{{percent .TalkTime.ShortCallsShare}}</td>{{end}}<td>{{money .Revenue}}</td><td>{{money .AverageOrderValue}}</td></tr>
+{{end}}</table>
+{{end}}<table>
+<tr><td>ЗП операторы, общая сумма</td><td>{{money .SummaryDepartmentSalary}}</td></tr>
+<tr><td>Премия операторы, общая сумма</td><td>{{money .SummaryDepartmentBonus}}</td></tr>
+<tr><td>Удержания за отмененные заказы</td><td>{{money .SummaryClawback}}</td></tr>
+<tr class="total"><td>Итого за неделю</td><td>{{money .SumToPay}}</td></tr>
+<tr><td>Выручка, общая сумма</td><td>{{money .TotalRevenue}}</td></tr>
+<tr><td>Средний чек</td><td>{{money .AverageOrderValue}}</td></tr>
+<tr><td>Доля расходов в выручке</td><td>{{percent .ExpensesToRevenue}}</td></tr>
+</table>
+<table>
+<tr><th>Дата</th><th>ун. зв.</th><th>Заказов принято</th><th>Конверсия</th><th>Праздник</th></tr>
+{{range .DailyStatistics}}<tr><td>{{.Date.Format "02.01.2006"}}</td><td>{{.UniqCalls}}</td><td>{{.OrdersCount}}</td><td>{{percent .Conversion}}</td><td>{{.Holiday}}</td></tr>
+{{end}}</table>
+{{if .Clawbacks}}<table>
+<tr><th>ФИО</th><th>Удержание</th><th>Сумма</th></tr>
+{{range .Clawbacks}}<tr><td>{{.Operator}}</td><td>{{.String}}</td><td>{{money .Amount}}</td></tr>
+{{end}}</table>
+{{end}}{{if .LateOrders}}<table>
+<tr><th>ФИО</th><th>Оплата подтвержденного заказа</th><th>Сумма</th></tr>
+{{range .LateOrders}}<tr><td>{{.Operator}}</td><td>{{.String}}</td><td>{{money .Amount}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+type htmlRenderer struct{}
+
+func (h htmlRenderer) Render(r entity.WeeklyReport, basePath string) (files []string, err error) {
+	path := basePath + ".html"
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}(file)
+
+	return []string{path}, htmlTemplate.Execute(file, r)
+}
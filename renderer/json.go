@@ -0,0 +1,280 @@
+package renderer
+
+import (
+	"callCenterReportMaker/entity"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const (
+	jsonSchemaVersion = 4
+	jsonDateLayout    = "2006-01-02"
+)
+
+// jsonReport is the schema shared with integrations, bump jsonSchemaVersion on incompatible changes
+type jsonReport struct {
+	SchemaVersion int             `json:"schema_version"`
+	DateFrom      string          `json:"date_from"`
+	DateTo        string          `json:"date_to"`
+	Operators     []jsonOperator  `json:"operators"`
+	Department    jsonDepartment  `json:"department"`
+	Expenses      jsonExpenses    `json:"expenses"`
+	Cities        []jsonCity      `json:"cities"`
+	Regions       []jsonCity      `json:"regions"`
+	Summary       jsonSummary     `json:"summary"`
+	Daily         []jsonDaily     `json:"daily"`
+	Clawbacks     []jsonClawback  `json:"clawbacks"`
+	LateOrders    []jsonLateOrder `json:"late_orders"`
+}
+
+type jsonOperator struct {
+	Name              string       `json:"name"`
+	Salary            float64      `json:"salary"`
+	HolidayPay        float64      `json:"holiday_pay"`
+	Bonus             float64      `json:"bonus"`
+	Clawback          float64      `json:"clawback"`
+	SummaryPayment    float64      `json:"summary_payment"`
+	OrdersCount       int          `json:"orders_count"`
+	PricePerOrder     float64      `json:"price_per_order"`
+	UniqCalls         int          `json:"uniq_calls"`
+	Conversion        float64      `json:"conversion"`
+	HoursWorked       float64      `json:"hours_worked"`
+	OrdersPerHour     float64      `json:"orders_per_hour"`
+	CallsPerHour      float64      `json:"calls_per_hour"`
+	ShiftConversion   float64      `json:"shift_conversion"`
+	OffShiftCalls     int          `json:"off_shift_calls"`
+	TalkTime          jsonTalkTime `json:"talk_time"`
+	Revenue           float64      `json:"revenue"`
+	AverageOrderValue float64      `json:"average_order_value"`
+	CommissionRate    float64      `json:"commission_rate"`
+}
+
+type jsonDepartment struct {
+	Payment       float64 `json:"payment"`
+	PricePerOrder float64 `json:"price_per_order"`
+}
+
+type jsonExpenses struct {
+	Items              []jsonExpenseItem `json:"items"`
+	Total              float64           `json:"total"`
+	TotalOrdersCount   int               `json:"total_orders_count"`
+	TotalPricePerOrder float64           `json:"total_price_per_order"`
+}
+
+type jsonExpenseItem struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+type jsonCity struct {
+	City              string       `json:"city"`
+	UniqCallsTotal    int          `json:"uniq_calls_total"`
+	UniqCallsReceived int          `json:"uniq_calls_received"`
+	UniqCallsMissed   int          `json:"uniq_calls_missed"`
+	OrdersCount       int          `json:"orders_count"`
+	LinkedOrdersCount int          `json:"linked_orders_count"`
+	Conversion        float64      `json:"conversion"`
+	TalkTime          jsonTalkTime `json:"talk_time"`
+	Revenue           float64      `json:"revenue"`
+	AverageOrderValue float64      `json:"average_order_value"`
+}
+
+// jsonTalkTime keeps the durations in seconds, the ones Mango data doesn't have are left out
+type jsonTalkTime struct {
+	AnsweredCalls     int      `json:"answered_calls"`
+	TotalTalkTime     *float64 `json:"total_talk_time,omitempty"`
+	AverageHandleTime *float64 `json:"average_handle_time,omitempty"`
+	AverageWaitTime   *float64 `json:"average_wait_time,omitempty"`
+	AverageRingTime   *float64 `json:"average_ring_time,omitempty"`
+	ShortCallsShare   *float64 `json:"short_calls_share,omitempty"`
+}
+
+type jsonSummary struct {
+	DepartmentSalary  float64 `json:"department_salary"`
+	DepartmentBonus   float64 `json:"department_bonus"`
+	Clawback          float64 `json:"clawback"`
+	SumToPay          float64 `json:"sum_to_pay"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	ExpensesToRevenue float64 `json:"expenses_to_revenue"`
+}
+
+type jsonDaily struct {
+	Date        string  `json:"date"`
+	UniqCalls   int     `json:"uniq_calls"`
+	OrdersCount int     `json:"orders_count"`
+	Conversion  float64 `json:"conversion"`
+	Holiday     string  `json:"holiday,omitempty"`
+}
+
+type jsonClawback struct {
+	OrderId  uint    `json:"order_id"`
+	Operator string  `json:"operator"`
+	Date     string  `json:"date"`
+	Status   string  `json:"status"`
+	PaidFrom string  `json:"paid_from"`
+	PaidTo   string  `json:"paid_to"`
+	Amount   float64 `json:"amount"`
+}
+
+// jsonLateOrder is a pending order of the period pendingFrom - pendingTo paid in this report
+type jsonLateOrder struct {
+	OrderId     uint    `json:"order_id"`
+	Operator    string  `json:"operator"`
+	Date        string  `json:"date"`
+	PendingFrom string  `json:"pending_from"`
+	PendingTo   string  `json:"pending_to"`
+	Amount      float64 `json:"amount"`
+}
+
+type jsonRenderer struct{}
+
+func (j jsonRenderer) Render(r entity.WeeklyReport, basePath string) ([]string, error) {
+	path := basePath + ".json"
+
+	data, err := json.MarshalIndent(j.convert(r), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{path}, os.WriteFile(path, data, 0644)
+}
+
+func (j jsonRenderer) convert(r entity.WeeklyReport) jsonReport {
+	operators := make([]jsonOperator, 0, len(r.OperatorReports))
+	for _, report := range r.OperatorReports {
+		operators = append(operators, jsonOperator{
+			Name:              report.Name,
+			Salary:            report.Salary,
+			HolidayPay:        report.HolidayPay,
+			Bonus:             report.Bonus,
+			Clawback:          report.Clawback,
+			SummaryPayment:    report.SummaryPayment,
+			OrdersCount:       report.OrdersCount,
+			PricePerOrder:     report.PricePerOrder,
+			UniqCalls:         report.UniqCalls,
+			Conversion:        report.Conversion,
+			HoursWorked:       report.HoursWorked,
+			OrdersPerHour:     report.OrdersPerHour,
+			CallsPerHour:      report.CallsPerHour,
+			ShiftConversion:   report.ShiftConversion,
+			OffShiftCalls:     report.OffShiftCalls,
+			TalkTime:          j.convertTalkTime(report.TalkTime, r.MeasuredDurations),
+			Revenue:           report.Revenue,
+			AverageOrderValue: report.AverageOrderValue,
+			CommissionRate:    report.CommissionRate,
+		})
+	}
+
+	expenseItems := make([]jsonExpenseItem, 0, len(r.Expenses))
+	for _, expense := range r.Expenses {
+		expenseItems = append(expenseItems, jsonExpenseItem{Name: expense.Name, Amount: expense.Amount})
+	}
+
+	daily := make([]jsonDaily, 0, len(r.DailyStatistics))
+	for _, statistic := range r.DailyStatistics {
+		daily = append(daily, jsonDaily{
+			Date:        statistic.Date.Format(jsonDateLayout),
+			UniqCalls:   statistic.UniqCalls,
+			OrdersCount: statistic.OrdersCount,
+			Conversion:  statistic.Conversion,
+			Holiday:     statistic.Holiday,
+		})
+	}
+
+	clawbacks := make([]jsonClawback, 0, len(r.Clawbacks))
+	for _, clawback := range r.Clawbacks {
+		clawbacks = append(clawbacks, jsonClawback{
+			OrderId:  clawback.Id,
+			Operator: clawback.Operator,
+			Date:     clawback.Date.Format(jsonDateLayout),
+			Status:   clawback.Status,
+			PaidFrom: clawback.DateFrom.Format(jsonDateLayout),
+			PaidTo:   clawback.DateTo.Format(jsonDateLayout),
+			Amount:   clawback.Amount,
+		})
+	}
+
+	lateOrders := make([]jsonLateOrder, 0, len(r.LateOrders))
+	for _, order := range r.LateOrders {
+		lateOrders = append(lateOrders, jsonLateOrder{
+			OrderId:     order.Id,
+			Operator:    order.Operator,
+			Date:        order.Date.Format(jsonDateLayout),
+			PendingFrom: order.DateFrom.Format(jsonDateLayout),
+			PendingTo:   order.DateTo.Format(jsonDateLayout),
+			Amount:      order.Amount,
+		})
+	}
+
+	return jsonReport{
+		SchemaVersion: jsonSchemaVersion,
+		DateFrom:      r.DateFrom.Format(jsonDateLayout),
+		DateTo:        r.DateTo.Format(jsonDateLayout),
+		Operators:     operators,
+		Department: jsonDepartment{
+			Payment:       r.DepartmentPayment,
+			PricePerOrder: r.DepartmentPricePerOrder,
+		},
+		Expenses: jsonExpenses{
+			Items:              expenseItems,
+			Total:              r.TotalExpenses,
+			TotalOrdersCount:   r.TotalOrdersCount,
+			TotalPricePerOrder: r.TotalPricePerOrder,
+		},
+		Cities:  j.convertCities(r.CityStatistics, r.MeasuredDurations),
+		Regions: j.convertCities(r.RegionStatistics, r.MeasuredDurations),
+		Summary: jsonSummary{
+			DepartmentSalary:  r.SummaryDepartmentSalary,
+			DepartmentBonus:   r.SummaryDepartmentBonus,
+			Clawback:          r.SummaryClawback,
+			SumToPay:          r.SumToPay,
+			Revenue:           r.TotalRevenue,
+			AverageOrderValue: r.AverageOrderValue,
+			ExpensesToRevenue: r.ExpensesToRevenue,
+		},
+		Daily:      daily,
+		Clawbacks:  clawbacks,
+		LateOrders: lateOrders,
+	}
+}
+
+func (j jsonRenderer) convertCities(statistics []entity.CityStatistic, measured entity.MeasuredDurations) []jsonCity {
+	cities := make([]jsonCity, 0, len(statistics))
+	for _, city := range statistics {
+		cities = append(cities, jsonCity{
+			City:              city.City,
+			UniqCallsTotal:    city.UniqCallsTotal,
+			UniqCallsReceived: city.UniqCallsReceived,
+			UniqCallsMissed:   city.UniqCallsMissed,
+			OrdersCount:       city.OrdersCount,
+			LinkedOrdersCount: city.LinkedOrdersCount,
+			Conversion:        city.Conversion,
+			TalkTime:          j.convertTalkTime(city.TalkTime, measured),
+			Revenue:           city.Revenue,
+			AverageOrderValue: city.AverageOrderValue,
+		})
+	}
+	return cities
+}
+
+func (j jsonRenderer) convertTalkTime(t entity.TalkTimeStatistic, measured entity.MeasuredDurations) jsonTalkTime {
+	result := jsonTalkTime{AnsweredCalls: t.AnsweredCalls}
+	if measured.TalkTime {
+		result.TotalTalkTime, result.AverageHandleTime = seconds(t.TotalTalkTime), seconds(t.AverageHandleTime)
+		result.ShortCallsShare = &t.ShortCallsShare
+	}
+	if measured.WaitTime {
+		result.AverageWaitTime = seconds(t.AverageWaitTime)
+	}
+	if measured.RingTime {
+		result.AverageRingTime = seconds(t.AverageRingTime)
+	}
+	return result
+}
+
+func seconds(d time.Duration) *float64 {
+	value := d.Seconds()
+	return &value
+}
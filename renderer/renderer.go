@@ -0,0 +1,65 @@
+package renderer
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/templates"
+	"fmt"
+)
+
+const (
+	Xlsx = "xlsx"
+	Csv  = "csv"
+	Json = "json"
+	Html = "html"
+)
+
+// Renderer writes the report next to basePath, adding its own extensions, and returns the written files
+type Renderer interface {
+	Render(report entity.WeeklyReport, basePath string) ([]string, error)
+}
+
+func New(format string, tmpl templates.Templates) (Renderer, error) {
+	switch format {
+	case Xlsx:
+		return xlsxRenderer{templates: tmpl}, nil
+	case Csv:
+		return csvRenderer{}, nil
+	case Json:
+		return jsonRenderer{}, nil
+	case Html:
+		return htmlRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат отчета %q, доступны: %s, %s, %s, %s", format, Xlsx, Csv, Json, Html)
+	}
+}
+
+// unmeasuredKeys are the layout keys of the durations Mango data doesn't have
+func unmeasuredKeys(measured entity.MeasuredDurations) map[string]bool {
+	keys := make(map[string]bool)
+	if !measured.TalkTime {
+		keys["averageHandleTime"], keys["totalTalkTime"], keys["shortCallsShare"] = true, true, true
+	}
+	if !measured.WaitTime {
+		keys["averageWaitTime"] = true
+	}
+	if !measured.RingTime {
+		keys["averageRingTime"] = true
+	}
+	return keys
+}
+
+func RenderAll(report entity.WeeklyReport, basePath string, formats []string, tmpl templates.Templates) ([]string, error) {
+	files := make([]string, 0, len(formats))
+	for _, format := range formats {
+		r, err := New(format, tmpl)
+		if err != nil {
+			return files, err
+		}
+		rendered, err := r.Render(report, basePath)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, rendered...)
+	}
+	return files, nil
+}
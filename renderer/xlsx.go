@@ -0,0 +1,571 @@
+package renderer
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/templates"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	currencyEvenFormat = 6
+	currencyDivFormat  = 7
+	percentFormat      = 10
+	hourlyFormat       = 2
+	durationFormat     = 46
+)
+
+type xlsxRenderer struct {
+	templates templates.Templates
+}
+
+var (
+	operatorColumnKeys = []string{"name", "salary", "bonus", "clawback", "summaryPayment", "ordersCount", "pricePerOrder", "uniqCalls", "conversion",
+		"hoursWorked", "ordersPerHour", "callsPerHour", "shiftConversion", "offShiftCalls", "averageHandleTime", "totalTalkTime", "shortCallsShare",
+		"revenue", "averageOrderValue"}
+	expenseRowKeys = []string{"department", "expenses", "total"}
+	cityRowKeys    = []string{"city", "uniqCallsTotal", "uniqCallsReceived", "uniqCallsMissed", "ordersCount", "linkedOrdersCount", "conversion",
+		"averageHandleTime", "averageWaitTime", "averageRingTime", "shortCallsShare", "revenue", "averageOrderValue"}
+	summaryRowKeys  = []string{"salary", "bonus", "clawback", "sumToPay", "revenue", "expensesToRevenue"}
+	dailyColumnKeys = []string{"date", "uniqCalls", "ordersCount", "conversion", "holiday"}
+)
+
+// xlsxStyles creates excelize styles on demand, one per fill, font and number format combination
+type xlsxStyles struct {
+	xl  *excelize.File
+	ids map[xlsxStyle]int
+}
+
+type xlsxStyle struct {
+	color  string
+	bold   bool
+	numFmt int
+}
+
+func (x xlsxRenderer) Render(r entity.WeeklyReport, basePath string) ([]string, error) {
+	layout, err := x.templates.XlsxLayout()
+	if err != nil {
+		return nil, err
+	}
+	//columns and rows missing from the layout are added hidden, the formulas refer to all of them
+	operatorColumns := completeColumns(layout.OperatorColumns, operatorColumnKeys)
+	expenseRows := completeRows(layout.ExpenseRows, expenseRowKeys)
+	cityRows := completeRows(layout.CityRows, cityRowKeys)
+	summaryRows := completeRows(layout.SummaryRows, summaryRowKeys)
+	dailyColumns := completeColumns(layout.DailyColumns, dailyColumnKeys)
+	unmeasured := unmeasuredKeys(r.MeasuredDurations)
+	for i := range operatorColumns {
+		operatorColumns[i].Hidden = operatorColumns[i].Hidden || unmeasured[operatorColumns[i].Key]
+	}
+	for i := range cityRows {
+		cityRows[i].Hidden = cityRows[i].Hidden || unmeasured[cityRows[i].Key]
+	}
+
+	path := basePath + ".xlsx"
+	xl := excelize.NewFile()
+	defer func(xl *excelize.File) {
+		_ = xl.Close()
+	}(xl)
+	dateLayout := "02.01"
+
+	sheet := r.DateFrom.Format(dateLayout) + " - " + r.DateTo.Format(dateLayout)
+	if err = xl.SetSheetName(xl.GetSheetName(0), sheet); err != nil {
+		return nil, err
+	}
+
+	st := xlsxStyles{xl: xl, ids: make(map[xlsxStyle]int)}
+
+	col := make(map[string]int, len(operatorColumns))
+	for i, column := range operatorColumns {
+		col[column.Key] = i
+		name, _ := excelize.ColumnNumberToName(i + 1)
+		if column.Width > 0 {
+			_ = xl.SetColWidth(sheet, name, name, column.Width)
+		}
+		if column.Hidden {
+			_ = xl.SetColVisible(sheet, name, false)
+		}
+	}
+
+	var rowIndex int
+
+	for i, column := range operatorColumns {
+		x.setValue(xl, sheet, i, rowIndex, column.Header, st.get(layout.HeaderColor, false, 0))
+	}
+
+	firstOperatorRow := rowIndex + 1
+	for i, report := range r.OperatorReports {
+		rowIndex++
+		x.setValue(xl, sheet, col["name"], rowIndex, report.Name, 0)
+		x.setValue(xl, sheet, col["salary"], rowIndex, report.Salary, st.get("", false, currencyEvenFormat))
+		x.setValue(xl, sheet, col["bonus"], rowIndex, report.Bonus, st.get("", false, currencyEvenFormat))
+		x.setValue(xl, sheet, col["clawback"], rowIndex, report.Clawback, st.get("", false, currencyEvenFormat))
+		x.setFormula(xl, sheet, col["summaryPayment"], rowIndex, fmt.Sprintf("%s+%s-%s", cell(col["salary"], rowIndex), cell(col["bonus"], rowIndex),
+			cell(col["clawback"], rowIndex)),
+			st.get("", false, currencyEvenFormat))
+
+		//boss's call stats are not shown
+		if i == len(r.OperatorReports)-1 {
+			continue
+		}
+		x.setValue(xl, sheet, col["ordersCount"], rowIndex, report.OrdersCount, 0)
+		x.setFormula(xl, sheet, col["pricePerOrder"], rowIndex, safeDivision(cell(col["summaryPayment"], rowIndex), cell(col["ordersCount"], rowIndex)),
+			st.get("", false, currencyDivFormat))
+		x.setValue(xl, sheet, col["uniqCalls"], rowIndex, report.UniqCalls, 0)
+		x.setFormula(xl, sheet, col["conversion"], rowIndex, safeDivision(cell(col["ordersCount"], rowIndex), cell(col["uniqCalls"], rowIndex)),
+			st.get("", false, percentFormat))
+		x.setValue(xl, sheet, col["hoursWorked"], rowIndex, report.HoursWorked, 0)
+		x.setFormula(xl, sheet, col["ordersPerHour"], rowIndex, safeDivision(cell(col["ordersCount"], rowIndex), cell(col["hoursWorked"], rowIndex)),
+			st.get("", false, hourlyFormat))
+		x.setFormula(xl, sheet, col["callsPerHour"], rowIndex, safeDivision(cell(col["uniqCalls"], rowIndex), cell(col["hoursWorked"], rowIndex)),
+			st.get("", false, hourlyFormat))
+		x.setValue(xl, sheet, col["shiftConversion"], rowIndex, report.ShiftConversion, st.get("", false, percentFormat))
+		x.setValue(xl, sheet, col["offShiftCalls"], rowIndex, report.OffShiftCalls, 0)
+		x.setValue(xl, sheet, col["averageHandleTime"], rowIndex, excelDuration(report.TalkTime.AverageHandleTime), st.get("", false, durationFormat))
+		x.setValue(xl, sheet, col["totalTalkTime"], rowIndex, excelDuration(report.TalkTime.TotalTalkTime), st.get("", false, durationFormat))
+		x.setValue(xl, sheet, col["shortCallsShare"], rowIndex, report.TalkTime.ShortCallsShare, st.get("", false, percentFormat))
+		x.setValue(xl, sheet, col["revenue"], rowIndex, report.Revenue, st.get("", false, currencyEvenFormat))
+		x.setFormula(xl, sheet, col["averageOrderValue"], rowIndex, safeDivision(cell(col["revenue"], rowIndex), cell(col["ordersCount"], rowIndex)),
+			st.get("", false, currencyDivFormat))
+	}
+	lastOperatorRow := rowIndex
+
+	//row colors span the columns up to the price per order
+	coloredColumns := max(col["ordersCount"], col["pricePerOrder"]) + 1
+	definedNames := make(map[string]string)
+	expenseNames := make([]string, 0, len(expenseRows))
+	var totalRow int
+	var totalStyle templates.XlsxRow
+	for _, row := range expenseRows {
+		switch row.Key {
+		case "department":
+			rowIndex++
+			x.setExpenseLabel(xl, sheet, col["name"], rowIndex, coloredColumns, row.Label, row, st)
+			x.setFormula(xl, sheet, col["salary"], rowIndex, sumColumn(col["summaryPayment"], firstOperatorRow, lastOperatorRow),
+				st.get(row.Color, row.Bold, currencyEvenFormat))
+			x.setFormula(xl, sheet, col["pricePerOrder"], rowIndex, safeDivision("DepartmentPayment", "TotalOrders"),
+				st.get(row.Color, row.Bold, currencyDivFormat))
+			definedNames["DepartmentPayment"] = absoluteCell(sheet, col["salary"], rowIndex)
+			expenseNames = append(expenseNames, "DepartmentPayment")
+		case "expenses":
+			//one editable row per expense item of the period
+			for _, expense := range r.Expenses {
+				rowIndex++
+				x.setExpenseLabel(xl, sheet, col["name"], rowIndex, coloredColumns, expense.Name, row, st)
+				x.setValue(xl, sheet, col["salary"], rowIndex, expense.Amount, st.get(row.Color, row.Bold, currencyEvenFormat))
+				name := expenseDefinedName(expense.Name, definedNames)
+				definedNames[name] = absoluteCell(sheet, col["salary"], rowIndex)
+				expenseNames = append(expenseNames, name)
+			}
+		case "total":
+			rowIndex++
+			x.setExpenseLabel(xl, sheet, col["name"], rowIndex, coloredColumns, row.Label, row, st)
+			totalRow, totalStyle = rowIndex, row
+			x.setValue(xl, sheet, col["ordersCount"], rowIndex, r.TotalOrdersCount, st.get(row.Color, row.Bold, 0))
+			x.setFormula(xl, sheet, col["pricePerOrder"], rowIndex, safeDivision("TotalExpenses", "TotalOrders"),
+				st.get(row.Color, row.Bold, currencyDivFormat))
+			definedNames["TotalExpenses"] = absoluteCell(sheet, col["salary"], rowIndex)
+			definedNames["TotalOrders"] = absoluteCell(sheet, col["ordersCount"], rowIndex)
+		}
+	}
+	x.setFormula(xl, sheet, col["salary"], totalRow, strings.Join(expenseNames, "+"),
+		st.get(totalStyle.Color, totalStyle.Bold, currencyEvenFormat))
+
+	cityRow := make(map[string]int, len(cityRows))
+	for i, row := range cityRows {
+		cityRow[row.Key] = rowIndex + 1 + i
+	}
+	for _, row := range cityRows {
+		rowIndex++
+		x.setValue(xl, sheet, 0, rowIndex, row.Label, 0)
+		x.hideRow(xl, sheet, rowIndex, row.Hidden)
+		for j := 0; j < len(r.CityStatistics); j++ {
+			//the last column holds the totals over all cities
+			isTotal := j == len(r.CityStatistics)-1
+			switch row.Key {
+			case "city":
+				x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].City, 0)
+			case "uniqCallsTotal", "uniqCallsReceived", "ordersCount", "linkedOrdersCount", "revenue":
+				if isTotal {
+					style := 0
+					if row.Key == "revenue" {
+						style = st.get("", false, currencyEvenFormat)
+					}
+					x.setFormula(xl, sheet, 1+j, rowIndex, sumRow(rowIndex, 1, j), style)
+					continue
+				}
+				switch row.Key {
+				case "uniqCallsTotal":
+					x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].UniqCallsTotal, 0)
+				case "uniqCallsReceived":
+					x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].UniqCallsReceived, 0)
+				case "ordersCount":
+					x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].OrdersCount, 0)
+				case "linkedOrdersCount":
+					x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].LinkedOrdersCount, 0)
+				case "revenue":
+					x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].Revenue, st.get("", false, currencyEvenFormat))
+				}
+			case "uniqCallsMissed":
+				x.setFormula(xl, sheet, 1+j, rowIndex, fmt.Sprintf("%s-%s", cell(1+j, cityRow["uniqCallsTotal"]), cell(1+j, cityRow["uniqCallsReceived"])), 0)
+			case "conversion":
+				x.setFormula(xl, sheet, 1+j, rowIndex, safeDivision(cell(1+j, cityRow["linkedOrdersCount"]), cell(1+j, cityRow["uniqCallsTotal"])),
+					st.get("", false, percentFormat))
+			case "averageHandleTime":
+				x.setValue(xl, sheet, 1+j, rowIndex, excelDuration(r.CityStatistics[j].TalkTime.AverageHandleTime), st.get("", false, durationFormat))
+			case "averageWaitTime":
+				x.setValue(xl, sheet, 1+j, rowIndex, excelDuration(r.CityStatistics[j].TalkTime.AverageWaitTime), st.get("", false, durationFormat))
+			case "averageRingTime":
+				x.setValue(xl, sheet, 1+j, rowIndex, excelDuration(r.CityStatistics[j].TalkTime.AverageRingTime), st.get("", false, durationFormat))
+			case "shortCallsShare":
+				x.setValue(xl, sheet, 1+j, rowIndex, r.CityStatistics[j].TalkTime.ShortCallsShare, st.get("", false, percentFormat))
+			case "averageOrderValue":
+				x.setFormula(xl, sheet, 1+j, rowIndex, safeDivision(cell(1+j, cityRow["revenue"]), cell(1+j, cityRow["ordersCount"])),
+					st.get("", false, currencyDivFormat))
+			}
+		}
+
+	}
+	//add empty string
+	rowIndex++
+
+	for _, row := range summaryRows {
+		rowIndex++
+		x.setValue(xl, sheet, 0, rowIndex, row.Label, 0)
+		x.hideRow(xl, sheet, rowIndex, row.Hidden)
+		switch row.Key {
+		case "salary":
+			x.setFormula(xl, sheet, 1, rowIndex, sumColumn(col["salary"], firstOperatorRow, lastOperatorRow), st.get("", false, currencyEvenFormat))
+		case "bonus":
+			x.setFormula(xl, sheet, 1, rowIndex, sumColumn(col["bonus"], firstOperatorRow, lastOperatorRow), st.get("", false, currencyEvenFormat))
+		case "clawback":
+			x.setFormula(xl, sheet, 1, rowIndex, sumColumn(col["clawback"], firstOperatorRow, lastOperatorRow), st.get("", false, currencyEvenFormat))
+		case "sumToPay":
+			x.setFormula(xl, sheet, 1, rowIndex, "DepartmentPayment", st.get("", false, currencyEvenFormat))
+		case "revenue":
+			x.setValue(xl, sheet, 1, rowIndex, r.TotalRevenue, st.get("", false, currencyEvenFormat))
+			definedNames["TotalRevenue"] = absoluteCell(sheet, 1, rowIndex)
+		case "expensesToRevenue":
+			x.setFormula(xl, sheet, 1, rowIndex, safeDivision("TotalExpenses", "TotalRevenue"), st.get("", false, percentFormat))
+		}
+	}
+
+	//add empty string
+	rowIndex++
+
+	rowIndex++
+	//daily columns share the sheet columns with the operator table, so hidden ones are left out instead
+	dailyCol := make(map[string]int, len(dailyColumns))
+	dailyHidden := make(map[string]bool, len(dailyColumns))
+	for i, column := range dailyColumns {
+		dailyCol[column.Key], dailyHidden[column.Key] = i, column.Hidden
+		if !column.Hidden {
+			x.setValue(xl, sheet, i, rowIndex, column.Header, 0)
+		}
+	}
+	firstDailyRow := rowIndex + 1
+	for _, statistic := range r.DailyStatistics {
+		rowIndex++
+		if !dailyHidden["date"] {
+			x.setValue(xl, sheet, dailyCol["date"], rowIndex, statistic.Date.Format(dateLayout), 0)
+		}
+		if !dailyHidden["uniqCalls"] {
+			x.setValue(xl, sheet, dailyCol["uniqCalls"], rowIndex, statistic.UniqCalls, 0)
+		}
+		if !dailyHidden["ordersCount"] {
+			x.setValue(xl, sheet, dailyCol["ordersCount"], rowIndex, statistic.OrdersCount, 0)
+		}
+		switch {
+		case dailyHidden["conversion"]:
+		case dailyHidden["uniqCalls"] || dailyHidden["ordersCount"]:
+			x.setValue(xl, sheet, dailyCol["conversion"], rowIndex, statistic.Conversion, st.get("", false, percentFormat))
+		default:
+			x.setFormula(xl, sheet, dailyCol["conversion"], rowIndex, safeDivision(cell(dailyCol["ordersCount"], rowIndex), cell(dailyCol["uniqCalls"], rowIndex)),
+				st.get("", false, percentFormat))
+		}
+		if !dailyHidden["holiday"] && statistic.Holiday != "" {
+			x.setValue(xl, sheet, dailyCol["holiday"], rowIndex, statistic.Holiday, 0)
+		}
+	}
+	lastDailyRow := rowIndex
+	if dailyHidden["date"] || dailyHidden["uniqCalls"] && dailyHidden["ordersCount"] {
+		lastDailyRow = firstDailyRow - 1
+	}
+
+	//the itemized deductions for the paid orders cancelled since
+	if len(r.Clawbacks) > 0 {
+		rowIndex += 2
+		x.setValue(xl, sheet, 0, rowIndex, "Удержания за отмененные заказы", st.get(layout.HeaderColor, true, 0))
+		for _, clawback := range r.Clawbacks {
+			rowIndex++
+			x.setValue(xl, sheet, 0, rowIndex, clawback.Operator, 0)
+			x.setValue(xl, sheet, 1, rowIndex, clawback.Amount, st.get("", false, currencyEvenFormat))
+			x.setValue(xl, sheet, 2, rowIndex, clawback.String(), 0)
+		}
+	}
+
+	if len(r.LateOrders) > 0 {
+		rowIndex += 2
+		x.setValue(xl, sheet, 0, rowIndex, "Оплата подтвержденных заказов прошлых периодов", st.get(layout.HeaderColor, true, 0))
+		for _, order := range r.LateOrders {
+			rowIndex++
+			x.setValue(xl, sheet, 0, rowIndex, order.Operator, 0)
+			x.setValue(xl, sheet, 1, rowIndex, order.Amount, st.get("", false, currencyEvenFormat))
+			x.setValue(xl, sheet, 2, rowIndex, order.String(), 0)
+		}
+	}
+
+	//the cities summed per region, the last row is the total
+	if len(r.RegionStatistics) > 0 {
+		rowIndex += 2
+		headers := []string{"Регион", "Звонков уникальных всего", "Звонков уникальных успешных", "Заказов принято", "Заказов по звонкам",
+			"Конверсия", "Выручка"}
+		for i, header := range headers {
+			x.setValue(xl, sheet, i, rowIndex, header, st.get(layout.HeaderColor, true, 0))
+		}
+		for _, region := range r.RegionStatistics {
+			rowIndex++
+			x.setValue(xl, sheet, 0, rowIndex, region.City, 0)
+			x.setValue(xl, sheet, 1, rowIndex, region.UniqCallsTotal, 0)
+			x.setValue(xl, sheet, 2, rowIndex, region.UniqCallsReceived, 0)
+			x.setValue(xl, sheet, 3, rowIndex, region.OrdersCount, 0)
+			x.setValue(xl, sheet, 4, rowIndex, region.LinkedOrdersCount, 0)
+			x.setFormula(xl, sheet, 5, rowIndex, safeDivision(cell(4, rowIndex), cell(1, rowIndex)), st.get("", false, percentFormat))
+			x.setValue(xl, sheet, 6, rowIndex, region.Revenue, st.get("", false, currencyEvenFormat))
+		}
+	}
+
+	//operators without the boss, cities without the totals column
+	charts := xlsxCharts{
+		operatorCol:      col,
+		firstOperatorRow: firstOperatorRow,
+		lastOperatorRow:  lastOperatorRow - 1,
+		cityRow:          cityRow,
+		citiesCount:      len(r.CityStatistics) - 1,
+		dailyCol:         dailyCol,
+		firstDailyRow:    firstDailyRow,
+		lastDailyRow:     lastDailyRow,
+	}
+	if err = x.addCharts(xl, sheet, len(operatorColumns)+1, charts); err != nil {
+		return nil, err
+	}
+
+	for name, refersTo := range definedNames {
+		if err = xl.SetDefinedName(&excelize.DefinedName{Name: name, RefersTo: refersTo}); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{path}, xl.SaveAs(path)
+}
+
+// xlsxCharts holds the positions of the chart source data on the sheet
+type xlsxCharts struct {
+	operatorCol                       map[string]int
+	firstOperatorRow, lastOperatorRow int
+	cityRow                           map[string]int
+	citiesCount                       int
+	dailyCol                          map[string]int
+	firstDailyRow, lastDailyRow       int
+}
+
+func (x xlsxRenderer) addCharts(xl *excelize.File, sheet string, chartCol int, c xlsxCharts) error {
+	chartSize := excelize.ChartDimension{Width: 640, Height: 320}
+
+	if c.lastOperatorRow >= c.firstOperatorRow {
+		orders := &excelize.Chart{
+			Type:      excelize.Col,
+			Dimension: chartSize,
+			Title:     []excelize.RichTextRun{{Text: "Заказы и конверсия по операторам"}},
+			Series:    []excelize.ChartSeries{c.operatorSeries(sheet, "ordersCount")},
+		}
+		conversion := &excelize.Chart{
+			Type:   excelize.Line,
+			YAxis:  excelize.ChartAxis{Secondary: true, NumFmt: excelize.ChartNumFmt{CustomNumFmt: "0%"}},
+			Series: []excelize.ChartSeries{c.operatorSeries(sheet, "conversion")},
+		}
+		if err := xl.AddChart(sheet, cell(chartCol, 0), orders, conversion); err != nil {
+			return err
+		}
+	}
+
+	if c.citiesCount > 0 {
+		cities := &excelize.Chart{
+			Type:      excelize.Col,
+			Dimension: chartSize,
+			Title:     []excelize.RichTextRun{{Text: "Уникальные звонки и заказы по городам"}},
+			Series:    []excelize.ChartSeries{c.citySeries(sheet, "uniqCallsTotal"), c.citySeries(sheet, "ordersCount")},
+		}
+		if err := xl.AddChart(sheet, cell(chartCol, 17), cities); err != nil {
+			return err
+		}
+	}
+
+	if c.lastDailyRow >= c.firstDailyRow {
+		daily := &excelize.Chart{
+			Type:      excelize.Line,
+			Dimension: chartSize,
+			Title:     []excelize.RichTextRun{{Text: "Динамика по дням"}},
+			Series:    []excelize.ChartSeries{c.dailySeries(sheet, "uniqCalls"), c.dailySeries(sheet, "ordersCount")},
+		}
+		if err := xl.AddChart(sheet, cell(chartCol, 34), daily); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c xlsxCharts) operatorSeries(sheet, key string) excelize.ChartSeries {
+	return excelize.ChartSeries{
+		Name:       absoluteRange(sheet, c.operatorCol[key], 0, c.operatorCol[key], 0),
+		Categories: absoluteRange(sheet, c.operatorCol["name"], c.firstOperatorRow, c.operatorCol["name"], c.lastOperatorRow),
+		Values:     absoluteRange(sheet, c.operatorCol[key], c.firstOperatorRow, c.operatorCol[key], c.lastOperatorRow),
+	}
+}
+
+func (c xlsxCharts) citySeries(sheet, key string) excelize.ChartSeries {
+	return excelize.ChartSeries{
+		Name:       absoluteRange(sheet, 0, c.cityRow[key], 0, c.cityRow[key]),
+		Categories: absoluteRange(sheet, 1, c.cityRow["city"], c.citiesCount, c.cityRow["city"]),
+		Values:     absoluteRange(sheet, 1, c.cityRow[key], c.citiesCount, c.cityRow[key]),
+	}
+}
+
+func (c xlsxCharts) dailySeries(sheet, key string) excelize.ChartSeries {
+	return excelize.ChartSeries{
+		Name:       absoluteRange(sheet, c.dailyCol[key], c.firstDailyRow-1, c.dailyCol[key], c.firstDailyRow-1),
+		Categories: absoluteRange(sheet, c.dailyCol["date"], c.firstDailyRow, c.dailyCol["date"], c.lastDailyRow),
+		Values:     absoluteRange(sheet, c.dailyCol[key], c.firstDailyRow, c.dailyCol[key], c.lastDailyRow),
+	}
+}
+
+func (st xlsxStyles) get(color string, bold bool, numFmt int) int {
+	key := xlsxStyle{color: color, bold: bold, numFmt: numFmt}
+	if key == (xlsxStyle{}) {
+		return 0
+	}
+	if id, ok := st.ids[key]; ok {
+		return id
+	}
+
+	style := &excelize.Style{NumFmt: numFmt}
+	if color != "" {
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{color}}
+	}
+	if bold {
+		style.Font = &excelize.Font{Bold: true}
+	}
+	id, err := st.xl.NewStyle(style)
+	if err != nil {
+		return 0
+	}
+	st.ids[key] = id
+	return id
+}
+
+func (x xlsxRenderer) setExpenseLabel(xl *excelize.File, sheet string, labelCol, row, coloredColumns int, label string, layout templates.XlsxRow, st xlsxStyles) {
+	for i := 0; i < coloredColumns; i++ {
+		x.setStyle(xl, sheet, i, row, st.get(layout.Color, layout.Bold, 0))
+	}
+	x.setValue(xl, sheet, labelCol, row, label, st.get(layout.Color, layout.Bold, 0))
+	x.hideRow(xl, sheet, row, layout.Hidden)
+}
+
+func (x xlsxRenderer) hideRow(xl *excelize.File, sheet string, row int, hidden bool) {
+	if hidden {
+		_ = xl.SetRowVisible(sheet, row+1, false)
+	}
+}
+
+func completeColumns(columns []templates.XlsxColumn, keys []string) []templates.XlsxColumn {
+	result := append([]templates.XlsxColumn{}, columns...)
+	for _, key := range keys {
+		if _, ok := templates.FindColumn(columns, key); !ok {
+			result = append(result, templates.XlsxColumn{Key: key, Hidden: true})
+		}
+	}
+	return result
+}
+
+func completeRows(rows []templates.XlsxRow, keys []string) []templates.XlsxRow {
+	result := append([]templates.XlsxRow{}, rows...)
+	for _, key := range keys {
+		if _, ok := templates.FindRow(rows, key); !ok {
+			result = append(result, templates.XlsxRow{Key: key, Hidden: true})
+		}
+	}
+	return result
+}
+
+func (x xlsxRenderer) setValue(xl *excelize.File, sheet string, col, row int, value interface{}, style int) {
+	_ = xl.SetCellValue(sheet, cell(col, row), value)
+	x.setStyle(xl, sheet, col, row, style)
+}
+
+func (x xlsxRenderer) setFormula(xl *excelize.File, sheet string, col, row int, formula string, style int) {
+	_ = xl.SetCellFormula(sheet, cell(col, row), formula)
+	x.setStyle(xl, sheet, col, row, style)
+}
+
+func (x xlsxRenderer) setStyle(xl *excelize.File, sheet string, col, row int, style int) {
+	if style != 0 {
+		_ = xl.SetCellStyle(sheet, cell(col, row), cell(col, row), style)
+	}
+}
+
+// cell converts zero based column and row indexes to the A1 notation
+func cell(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col+1, row+1)
+	return name
+}
+
+// expenseDefinedName makes the workbook name of the expense input, e.g. Expense_Оплата_СМС_сервиса,
+// the prefix keeps it from looking like a cell reference and a suffix tells apart the expenses of the same name
+func expenseDefinedName(expenseName string, definedNames map[string]string) string {
+	name := "Expense_" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(expenseName))
+	unique := name
+	for i := 2; definedNames[unique] != ""; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	return unique
+}
+
+func absoluteCell(sheet string, col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col+1, row+1, true)
+	return fmt.Sprintf("'%s'!%s", sheet, name)
+}
+
+func absoluteRange(sheet string, fromCol, fromRow, toCol, toRow int) string {
+	from, _ := excelize.CoordinatesToCellName(fromCol+1, fromRow+1, true)
+	to, _ := excelize.CoordinatesToCellName(toCol+1, toRow+1, true)
+	return fmt.Sprintf("'%s'!%s:%s", sheet, from, to)
+}
+
+func sumColumn(col, fromRow, toRow int) string {
+	return fmt.Sprintf("SUM(%s:%s)", cell(col, fromRow), cell(col, toRow))
+}
+
+func sumRow(row, fromCol, toCol int) string {
+	if toCol < fromCol {
+		return "0"
+	}
+	return fmt.Sprintf("SUM(%s:%s)", cell(fromCol, row), cell(toCol, row))
+}
+
+// excelDuration converts the duration to the fraction of a day Excel keeps time in
+func excelDuration(d time.Duration) float64 {
+	return d.Hours() / 24
+}
+
+func safeDivision(dividend, divisor string) string {
+	return fmt.Sprintf("IF(%s>0,%s/%s,0)", divisor, dividend, divisor)
+}
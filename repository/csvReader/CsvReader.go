@@ -14,8 +14,8 @@ const (
 	dateLayout      = "02.01.2006"
 )
 
-func New() CsvReader {
-	return &csvReader{}
+func New(location *time.Location) CsvReader {
+	return &csvReader{location: location}
 }
 
 type CsvReader interface {
@@ -23,7 +23,8 @@ type CsvReader interface {
 }
 
 type csvReader struct {
-	reader *csv.Reader
+	reader   *csv.Reader
+	location *time.Location
 }
 
 func (r *csvReader) GetHistory(source string) ([]entity.HistoryRecord, error) {
@@ -63,7 +64,7 @@ func (r *csvReader) convertRawDataToHistoryRecords(data [][]string) []entity.His
 			Operator:   datum[2],
 			LineNumber: datum[3],
 		}
-		currentRecord.Date, _ = time.ParseInLocation(dateLayout, datum[0], time.Local)
+		currentRecord.Date, _ = time.ParseInLocation(dateLayout, datum[0], r.location)
 		historyRecords = append(historyRecords, currentRecord)
 	}
 	return historyRecords
@@ -3,30 +3,94 @@ package database
 import (
 	"callCenterReportMaker/entity"
 	"database/sql"
+	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"log"
+	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
-	dbDateLayout = "2006-01-02"
+	dbDateLayout     = "2006-01-02"
+	dbDateTimeLayout = "2006-01-02 15:04:05"
 )
 
+var columnNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DurationColumns name the mango_history columns with the talk, wait and ring time of a call,
+// an empty name leaves the duration zero
+type DurationColumns struct {
+	TalkTime string
+	WaitTime string
+	RingTime string
+}
+
+func (c DurationColumns) Validate() error {
+	for _, column := range []string{c.TalkTime, c.WaitTime, c.RingTime} {
+		if column != "" && !columnNameRegexp.MatchString(column) {
+			return fmt.Errorf("недопустимое имя столбца %q", column)
+		}
+	}
+	return nil
+}
+
+func (c DurationColumns) selectExpressions() string {
+	expressions := make([]string, 0, 3)
+	for _, column := range []string{c.TalkTime, c.WaitTime, c.RingTime} {
+		if column == "" {
+			expressions = append(expressions, "''")
+			continue
+		}
+		expressions = append(expressions, fmt.Sprintf("COALESCE(%s, '')", column))
+	}
+	return strings.Join(expressions, ", ")
+}
+
+// OrderColumns name the orders columns read besides the fixed ones, an empty name leaves the value zero
+type OrderColumns struct {
+	Amount string
+}
+
+func (c OrderColumns) Validate() error {
+	if c.Amount != "" && !columnNameRegexp.MatchString(c.Amount) {
+		return fmt.Errorf("недопустимое имя столбца %q", c.Amount)
+	}
+	return nil
+}
+
+func (c OrderColumns) amountExpression() string {
+	if c.Amount == "" {
+		return "0"
+	}
+	return fmt.Sprintf("COALESCE(orders.%s, 0)", c.Amount)
+}
+
 type Database interface {
 	GetHistory(frameWidthInDays int) ([]entity.HistoryRecord, error)
 	GetOrders(dateFrom, dateTo time.Time) ([]entity.Orders, error)
+	GetPendingOrders(dateFrom, dateTo time.Time) ([]entity.Orders, error)
+	GetOrderStatuses(ids []uint) (map[uint]string, error)
 	GetUniqCallsByOperators(dateFrom, dateTo time.Time) ([]entity.DatabaseStatistic, error)
+	GetOperatorCalls(dateFrom, dateTo time.Time) ([]entity.HistoryRecord, error)
+	WithOperators(operatorsNames []string) Database
 }
 
+// database treats the stored dates as wall clock time of the business location, the period ends are whole days
 type database struct {
-	db        *sql.DB
-	operators []string
+	db              *sql.DB
+	operators       []string
+	location        *time.Location
+	durationColumns DurationColumns
+	orderStatuses   entity.OrderStatuses
+	orderColumns    OrderColumns
 }
 
-func New(host, port, dbname, user, password string, operatorsNames []string) Database {
+func New(host, port, dbname, user, password string, operatorsNames []string, location *time.Location, durationColumns DurationColumns,
+	orderStatuses entity.OrderStatuses, orderColumns OrderColumns) Database {
 	cfg := mysql.Config{
 		User:                 user,
 		Passwd:               password,
@@ -34,6 +98,7 @@ func New(host, port, dbname, user, password string, operatorsNames []string) Dat
 		Addr:                 host + ":" + port,
 		DBName:               dbname,
 		AllowNativePasswords: true,
+		Loc:                  location,
 	}
 	var err error
 	var db database
@@ -47,20 +112,31 @@ func New(host, port, dbname, user, password string, operatorsNames []string) Dat
 		log.Fatal(err)
 	}
 	db.operators = operatorsNames
+	db.location = location
+	db.durationColumns = durationColumns
+	db.orderStatuses = orderStatuses
+	db.orderColumns = orderColumns
 
 	return db
 }
 
+// WithOperators returns the database sharing the connection with another operators list
+func (d database) WithOperators(operatorsNames []string) Database {
+	d.operators = operatorsNames
+	return d
+}
+
 func (d database) GetHistory(frameWidthInDays int) ([]entity.HistoryRecord, error) {
 	//goland:noinspection SpellCheckingInspection
 	rows, err := d.db.Query(
-		`SELECT data_postupil_vkompan, tel_kto_zvonil, komu_zvonil, kuda_zvonil FROM mango_history
+		`SELECT data_postupil_vkompan, COALESCE(tel_kto_zvonil, ''), COALESCE(komu_zvonil, ''), COALESCE(kuda_zvonil, ''), `+d.durationColumns.selectExpressions()+` FROM mango_history
 					WHERE
-    			data_postupil_vkompan BETWEEN ? AND ?
+    			data_postupil_vkompan >= ? AND data_postupil_vkompan < ?
     			AND (gruppa LIKE '7 Операторы%' OR gruppa LIKE '%Курск первоначальные обращения' OR gruppa LIKE '04 Курск')
-    			AND napravlenie LIKE 'Входящий%';`,
-		time.Now().AddDate(0, 0, -frameWidthInDays),
-		time.Now(),
+    			AND napravlenie LIKE 'Входящий%'
+				ORDER BY data_postupil_vkompan;`,
+		d.startOfDay(time.Now()).AddDate(0, 0, -frameWidthInDays),
+		d.startOfDay(time.Now()).AddDate(0, 0, 1),
 	)
 	if err != nil {
 		return nil, err
@@ -69,58 +145,112 @@ func (d database) GetHistory(frameWidthInDays int) ([]entity.HistoryRecord, erro
 	historyRecords := make([]entity.HistoryRecord, 0, 10000)
 
 	for rows.Next() {
-		var dateStr, abonent, operator, lineNumber string
-		_ = rows.Scan(&dateStr, &abonent, &operator, &lineNumber)
+		var dateStr, abonent, operator, lineNumber, talkTime, waitTime, ringTime string
+		if err = rows.Scan(&dateStr, &abonent, &operator, &lineNumber, &talkTime, &waitTime, &ringTime); err != nil {
+			return nil, fmt.Errorf("mango_history: %w", err)
+		}
 
 		historyRecords = append(historyRecords, entity.HistoryRecord{
 			Date:       d.parseTime(dateStr),
 			Abonent:    abonent,
 			Operator:   d.normalizeOperatorName(operator),
 			LineNumber: lineNumber,
+			TalkTime:   parseDuration(talkTime),
+			WaitTime:   parseDuration(waitTime),
+			RingTime:   parseDuration(ringTime),
 		})
 	}
 
-	return historyRecords, err
+	return historyRecords, rows.Err()
 }
 
+// GetOrders returns the counted orders of the period, see entity.OrderStatuses
 func (d database) GetOrders(dateFrom, dateTo time.Time) ([]entity.Orders, error) {
+	return d.getOrders(dateFrom, dateTo, entity.OrderCounted)
+}
+
+// GetPendingOrders returns the orders of the period that are neither counted nor cancelled yet
+func (d database) GetPendingOrders(dateFrom, dateTo time.Time) ([]entity.Orders, error) {
+	return d.getOrders(dateFrom, dateTo, entity.OrderPending)
+}
+
+func (d database) getOrders(dateFrom, dateTo time.Time, group string) ([]entity.Orders, error) {
+	from, to := d.periodBounds(dateFrom, dateTo)
 	//goland:noinspection SpellCheckingInspection
 	rows, err := d.db.Query(
-		`SELECT orders.id, orders.date_add_, cities.name, users.fio  FROM orders
+		`SELECT orders.id, orders.date_add_, cities.name, COALESCE(orders.phone, ''), COALESCE(users.fio, ''), orders.status, `+d.orderColumns.amountExpression()+` FROM orders
 					JOIN cities on cities.city_id = orders.city_id
     				LEFT JOIN users on users.id = orders.id_operator
-					WHERE date_add_ BETWEEN ? AND ? 
-					AND orders.status <> 5;`, dateFrom, dateTo)
+					WHERE date_add_ >= ? AND date_add_ < ?;`, from, to)
 	if err != nil {
 		return nil, err
 	}
 
 	orders := make([]entity.Orders, 0, 500)
 	for rows.Next() {
-		var dateStr, city, operator string
+		var dateStr, city, phone, operator string
 		var id uint
-		_ = rows.Scan(&id, &dateStr, &city, &operator)
+		var status int
+		var amount float64
+		if err = rows.Scan(&id, &dateStr, &city, &phone, &operator, &status, &amount); err != nil {
+			return nil, fmt.Errorf("orders: %w", err)
+		}
 
+		if d.orderStatuses.Group(status) != group {
+			continue
+		}
 		orders = append(orders, entity.Orders{
 			Id:       id,
 			Date:     d.parseTime(dateStr),
 			City:     city,
 			Operator: d.normalizeOperatorName(operator),
+			Phone:    phone,
+			Status:   group,
+			Amount:   amount,
 		})
 	}
 
-	return orders, nil
+	return orders, rows.Err()
+}
+
+// GetOrderStatuses returns the current status groups of the orders
+func (d database) GetOrderStatuses(ids []uint) (map[uint]string, error) {
+	statuses := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := d.db.Query(
+		`SELECT id, status FROM orders WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`);`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var id uint
+		var status int
+		if err = rows.Scan(&id, &status); err != nil {
+			return nil, err
+		}
+		statuses[id] = d.orderStatuses.Group(status)
+	}
+	return statuses, rows.Err()
 }
 
 func (d database) GetUniqCallsByOperators(dateFrom, dateTo time.Time) ([]entity.DatabaseStatistic, error) {
+	from, to := d.periodBounds(dateFrom, dateTo)
 	//goland:noinspection SpellCheckingInspection
 	rows, err := d.db.Query(
-		`SELECT komu_zvonil, napravlenie mango_history FROM mango_history
+		`SELECT COALESCE(komu_zvonil, ''), napravlenie FROM mango_history
 				WHERE
-				data_postupil_vkompan BETWEEN ? AND ?
+				data_postupil_vkompan >= ? AND data_postupil_vkompan < ?
 				AND unik = 1
 				AND (napravlenie = 'Входящий внешний вызов' OR napravlenie = 'Исходящий внешний вызов')
-				AND (gruppa LIKE '7 Операторы%');`, dateFrom, dateTo)
+				AND (gruppa LIKE '7 Операторы%');`, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +261,9 @@ func (d database) GetUniqCallsByOperators(dateFrom, dateTo time.Time) ([]entity.
 
 	for rows.Next() {
 		var operator, direction string
-		_ = rows.Scan(&operator, &direction)
+		if err = rows.Scan(&operator, &direction); err != nil {
+			return nil, fmt.Errorf("mango_history: %w", err)
+		}
 
 		if slices.Contains(d.operators, operator) {
 			if stat, ok := statMap[operator]; ok {
@@ -153,14 +285,78 @@ func (d database) GetUniqCallsByOperators(dateFrom, dateTo time.Time) ([]entity.
 
 	sort.Slice(statistics, func(i, j int) bool { return statistics[i].Operator < statistics[j].Operator })
 
-	return statistics, nil
+	return statistics, rows.Err()
 }
 
+// GetOperatorCalls returns the operators' incoming and outgoing external calls of the period,
+// unlike GetHistory it has the calls the operators made themselves
+func (d database) GetOperatorCalls(dateFrom, dateTo time.Time) ([]entity.HistoryRecord, error) {
+	from, to := d.periodBounds(dateFrom, dateTo)
+	//goland:noinspection SpellCheckingInspection
+	rows, err := d.db.Query(
+		`SELECT data_postupil_vkompan, COALESCE(tel_kto_zvonil, ''), COALESCE(komu_zvonil, ''), COALESCE(kuda_zvonil, '') FROM mango_history
+				WHERE
+				data_postupil_vkompan >= ? AND data_postupil_vkompan < ?
+				AND (napravlenie = 'Входящий внешний вызов' OR napravlenie = 'Исходящий внешний вызов')
+				AND (gruppa LIKE '7 Операторы%')
+				ORDER BY data_postupil_vkompan;`, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]entity.HistoryRecord, 0, 10000)
+	for rows.Next() {
+		var dateStr, abonent, operator, lineNumber string
+		if err = rows.Scan(&dateStr, &abonent, &operator, &lineNumber); err != nil {
+			return nil, fmt.Errorf("mango_history: %w", err)
+		}
+		records = append(records, entity.HistoryRecord{
+			Date:       d.parseTime(dateStr),
+			Abonent:    abonent,
+			Operator:   d.normalizeOperatorName(operator),
+			LineNumber: lineNumber,
+		})
+	}
+	return records, rows.Err()
+}
+
+// parseTime leaves the date zero when it can't be read, the report data check lists such records
 func (d database) parseTime(dateStr string) time.Time {
-	date, _ := time.Parse(dbDateLayout, dateStr)
+	date, err := time.ParseInLocation(dbDateTimeLayout, dateStr, d.location)
+	if err != nil {
+		date, _ = time.ParseInLocation(dbDateLayout, dateStr, d.location)
+	}
 	return date
 }
 
+// parseDuration reads both the seconds count and the "15:04:05" time Mango exports durations as
+func parseDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	var duration time.Duration
+	for _, part := range strings.Split(value, ":") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		duration = duration*60 + time.Duration(number)*time.Second
+	}
+	return duration
+}
+
+// startOfDay returns the midnight of the date's day in the business location
+func (d database) startOfDay(date time.Time) time.Time {
+	year, month, day := date.In(d.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, d.location)
+}
+
+// periodBounds turns the period of whole days into the half-open range of the queries
+func (d database) periodBounds(dateFrom, dateTo time.Time) (from, to time.Time) {
+	return d.startOfDay(dateFrom), d.startOfDay(dateTo).AddDate(0, 0, 1)
+}
+
 func (d database) normalizeOperatorName(name string) string {
 	for _, normalizedName := range d.operators {
 		if strings.Contains(strings.ToLower(name), strings.ToLower(normalizedName)) {
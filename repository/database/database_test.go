@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDayBoundaries(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := database{location: berlin}
+	springChange := time.Date(2024, time.March, 31, 0, 0, 0, 0, berlin)
+	autumnChange := time.Date(2024, time.October, 27, 0, 0, 0, 0, berlin)
+	monday := time.Date(2024, time.March, 18, 0, 0, 0, 0, berlin)
+	nextMonday := time.Date(2024, time.March, 25, 0, 0, 0, 0, berlin)
+
+	t.Run("startOfDay", func(t *testing.T) {
+		for _, date := range []time.Time{
+			springChange,
+			time.Date(2024, time.March, 31, 23, 59, 59, 0, berlin),
+			time.Date(2024, time.March, 31, 2, 30, 0, 0, berlin),
+			time.Date(2024, time.March, 30, 23, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 31, 21, 59, 0, 0, time.UTC),
+		} {
+			if got := d.startOfDay(date); !got.Equal(springChange) || got.Location() != berlin {
+				t.Errorf("startOfDay(%s) = %s, want %s", date, got, springChange)
+			}
+		}
+	})
+
+	tests := []struct {
+		name             string
+		dateFrom, dateTo time.Time
+		from, to         time.Time
+		length           time.Duration
+	}{
+		{"whole week", monday, nextMonday.AddDate(0, 0, -1), monday, nextMonday, 7 * 24 * time.Hour},
+		{"dates with a time of day", monday.Add(15 * time.Hour), nextMonday.Add(-time.Minute), monday, nextMonday, 7 * 24 * time.Hour},
+		{"UTC dates of the next business day", time.Date(2024, time.March, 17, 23, 30, 0, 0, time.UTC), time.Date(2024, time.March, 23, 23, 30, 0, 0, time.UTC),
+			monday, nextMonday, 7 * 24 * time.Hour},
+		{"spring DST change", springChange, springChange, springChange, springChange.AddDate(0, 0, 1), 23 * time.Hour},
+		{"autumn DST change", autumnChange, autumnChange, autumnChange, autumnChange.AddDate(0, 0, 1), 25 * time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, to := d.periodBounds(test.dateFrom, test.dateTo)
+			if !from.Equal(test.from) || !to.Equal(test.to) {
+				t.Errorf("periodBounds(%s, %s) = [%s, %s), want [%s, %s)", test.dateFrom, test.dateTo, from, to, test.from, test.to)
+			}
+			if length := to.Sub(from); length != test.length {
+				t.Errorf("range is %s long, want %s", length, test.length)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package paidOrders
+
+import (
+	"callCenterReportMaker/entity"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// retentionDays bounds the ledger, orders cancelled later than that are not clawed back
+const retentionDays = 180
+
+// PaidOrders is the ledger of the orders paid in approved payrolls and of the pending ones waiting to be paid
+type PaidOrders interface {
+	GetPaidOrders() ([]entity.PaidOrder, error)
+	GetPendingOrders() ([]entity.PendingOrder, error)
+	Settle(paid []entity.PaidOrder, clawbacks []entity.Clawback, pending []entity.PendingOrder) error
+}
+
+type paidOrders struct {
+	mu          sync.Mutex
+	path        string
+	pendingPath string
+	location    *time.Location
+}
+
+func New(path, pendingPath string, location *time.Location) PaidOrders {
+	return &paidOrders{path: path, pendingPath: pendingPath, location: location}
+}
+
+func (p *paidOrders) GetPaidOrders() ([]entity.PaidOrder, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var stored []entity.PaidOrder
+	return stored, p.load(p.path, &stored)
+}
+
+func (p *paidOrders) GetPendingOrders() ([]entity.PendingOrder, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var stored []entity.PendingOrder
+	return stored, p.load(p.pendingPath, &stored)
+}
+
+// Settle records the orders of an approved payroll and removes the ones clawed back in it,
+// the pending orders replace the stored ones
+func (p *paidOrders) Settle(paid []entity.PaidOrder, clawbacks []entity.Clawback, pending []entity.PendingOrder) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var stored []entity.PaidOrder
+	if err := p.load(p.path, &stored); err != nil {
+		return err
+	}
+
+	settled := make(map[uint]bool, len(paid)+len(clawbacks))
+	for _, clawback := range clawbacks {
+		settled[clawback.Id] = true
+	}
+	for _, order := range paid {
+		settled[order.Id] = true
+	}
+
+	expired := time.Now().In(p.location).AddDate(0, 0, -retentionDays)
+	result := make([]entity.PaidOrder, 0, len(stored)+len(paid))
+	for _, order := range stored {
+		if !settled[order.Id] && order.DateTo.After(expired) {
+			result = append(result, order)
+		}
+	}
+	for _, order := range paid {
+		if !isClawedBack(order.Id, clawbacks) {
+			result = append(result, order)
+		}
+	}
+	if err := p.save(p.path, result); err != nil {
+		return err
+	}
+
+	waiting := make([]entity.PendingOrder, 0, len(pending))
+	for _, order := range pending {
+		if order.DateTo.After(expired) {
+			waiting = append(waiting, order)
+		}
+	}
+	return p.save(p.pendingPath, waiting)
+}
+
+func (p *paidOrders) load(path string, stored interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, stored); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *paidOrders) save(path string, stored interface{}) error {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func isClawedBack(id uint, clawbacks []entity.Clawback) bool {
+	for _, clawback := range clawbacks {
+		if clawback.Id == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package shifts
+
+import (
+	"callCenterReportMaker/entity"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	comma       = ';'
+	dateLayout  = "02.01.2006"
+	clockLayout = "15:04"
+	dayLayout   = "2006-01-02"
+)
+
+// Shifts keeps the operators' schedule uploaded as CSV or XLSX with the columns
+// Дата (ДД.ММ.ГГГГ), Оператор, Начало (ЧЧ:ММ), Конец (ЧЧ:ММ) and a header row;
+// an empty start marks a day off, a shift ending before it starts ends on the next day
+type Shifts interface {
+	Import(source string) (imported int, err error)
+	GetShifts(dateFrom, dateTo time.Time) ([]entity.Shift, error)
+	WithOperators(operatorsNames []string) Shifts
+}
+
+type shifts struct {
+	mu        *sync.Mutex
+	path      string
+	operators []string
+	location  *time.Location
+}
+
+func New(path string, operatorsNames []string, location *time.Location) Shifts {
+	return &shifts{mu: &sync.Mutex{}, path: path, operators: operatorsNames, location: location}
+}
+
+// WithOperators returns the shifts sharing the file and its lock with another operators list
+func (s *shifts) WithOperators(operatorsNames []string) Shifts {
+	return &shifts{mu: s.mu, path: s.path, operators: operatorsNames, location: s.location}
+}
+
+// Import replaces the stored shifts of the operators and days present in the source
+func (s *shifts) Import(source string) (int, error) {
+	rows, err := s.readRows(source)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := make([]entity.Shift, 0, len(rows))
+	replaced := make(map[string]bool, len(rows))
+	for i, row := range rows {
+		if i == 0 || len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		if len(row) < 2 {
+			return 0, fmt.Errorf("строка %d: нужны дата, оператор, начало и конец смены", i+1)
+		}
+		date, err := time.ParseInLocation(dateLayout, strings.TrimSpace(row[0]), s.location)
+		if err != nil {
+			return 0, fmt.Errorf("строка %d: %w", i+1, err)
+		}
+		operator := s.normalizeOperatorName(strings.TrimSpace(row[1]))
+		replaced[shiftKey(operator, date)] = true
+
+		if len(row) < 3 || strings.TrimSpace(row[2]) == "" {
+			continue
+		}
+		if len(row) < 4 {
+			return 0, fmt.Errorf("строка %d: не указан конец смены", i+1)
+		}
+		from, err := s.atClock(date, row[2])
+		if err != nil {
+			return 0, fmt.Errorf("строка %d: %w", i+1, err)
+		}
+		to, err := s.atClock(date, row[3])
+		if err != nil {
+			return 0, fmt.Errorf("строка %d: %w", i+1, err)
+		}
+		if !to.After(from) {
+			to = to.AddDate(0, 0, 1)
+		}
+		imported = append(imported, entity.Shift{Operator: operator, From: from, To: to})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	result := make([]entity.Shift, 0, len(stored)+len(imported))
+	for _, shift := range stored {
+		if !replaced[shiftKey(shift.Operator, shift.From)] {
+			result = append(result, shift)
+		}
+	}
+	result = append(result, imported...)
+	sort.Slice(result, func(i, j int) bool { return result[i].From.Before(result[j].From) })
+
+	return len(imported), s.save(result)
+}
+
+// GetShifts returns the shifts starting on the days of the period
+func (s *shifts) GetShifts(dateFrom, dateTo time.Time) ([]entity.Shift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	periodEnd := dateTo.AddDate(0, 0, 1)
+	result := make([]entity.Shift, 0, len(stored))
+	for _, shift := range stored {
+		if !shift.From.Before(dateFrom) && shift.From.Before(periodEnd) {
+			result = append(result, shift)
+		}
+	}
+	return result, nil
+}
+
+func (s *shifts) readRows(source string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".csv":
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer func(file *os.File) {
+			_ = file.Close()
+		}(file)
+
+		reader := csv.NewReader(file)
+		reader.Comma = comma
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ".xlsx":
+		xl, err := excelize.OpenFile(source)
+		if err != nil {
+			return nil, err
+		}
+		defer func(xl *excelize.File) {
+			_ = xl.Close()
+		}(xl)
+
+		return xl.GetRows(xl.GetSheetName(0))
+	default:
+		return nil, fmt.Errorf("график смен принимается в CSV или XLSX, а не %q", filepath.Ext(source))
+	}
+}
+
+func (s *shifts) atClock(date time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse(clockLayout, strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), parsed.Hour(), parsed.Minute(), 0, 0, s.location), nil
+}
+
+func (s *shifts) load() ([]entity.Shift, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []entity.Shift
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	for i := range stored {
+		stored[i].From, stored[i].To = stored[i].From.In(s.location), stored[i].To.In(s.location)
+	}
+	return stored, nil
+}
+
+func (s *shifts) save(stored []entity.Shift) error {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func (s *shifts) normalizeOperatorName(name string) string {
+	for _, normalizedName := range s.operators {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(normalizedName)) {
+			return normalizedName
+		}
+	}
+	return name
+}
+
+func shiftKey(operator string, date time.Time) string {
+	return operator + "|" + date.Format(dayLayout)
+}
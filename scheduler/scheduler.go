@@ -0,0 +1,319 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DailyStats    = "dailyStats"
+	WeeklyDraft   = "weeklyDraft"
+	Anomalies     = "anomalies"
+	MonthlyRollup = "monthlyRollup"
+)
+
+var ErrUnknownJob = errors.New("неизвестная задача")
+
+// Job is a scheduled job declared in config, Cron is a standard five field expression. The job runs in the scheduler
+// time zone unless Timezone is set or Cron starts with CRON_TZ=<zone>
+type Job struct {
+	Name     string
+	Type     string
+	Cron     string
+	Timezone string
+	Chats    []int64
+	Paused   bool
+}
+
+func (j Job) Validate() error {
+	switch j.Type {
+	case DailyStats, WeeklyDraft, Anomalies, MonthlyRollup:
+	default:
+		return fmt.Errorf("задача %q: неизвестный тип %q", j.Name, j.Type)
+	}
+	if j.Timezone != "" && hasTimezone(j.Cron) {
+		return fmt.Errorf("задача %q: часовой пояс указан и в timezone, и в cron", j.Name)
+	}
+	if _, err := cron.ParseStandard(j.spec()); err != nil {
+		return fmt.Errorf("задача %q: %w", j.Name, err)
+	}
+	return nil
+}
+
+// spec is the cron expression with the job's own time zone
+func (j Job) spec() string {
+	if j.Timezone == "" {
+		return j.Cron
+	}
+	return fmt.Sprintf("CRON_TZ=%s %s", j.Timezone, j.Cron)
+}
+
+// location is the job's own time zone, if it has one
+func (j Job) location() (*time.Location, bool) {
+	zone := j.Timezone
+	if hasTimezone(j.Cron) {
+		zone = strings.SplitN(strings.Fields(j.Cron)[0], "=", 2)[1]
+	}
+	if zone == "" {
+		return nil, false
+	}
+	location, err := time.LoadLocation(zone)
+	return location, err == nil
+}
+
+func hasTimezone(spec string) bool {
+	return strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=")
+}
+
+// Handler runs a job of its type for the job's chats
+type Handler func(chats []int64) error
+
+type Status struct {
+	Job
+	Next    time.Time
+	LastRun time.Time
+	LastErr error
+}
+
+func (s Status) String() string {
+	state := "активна"
+	if s.Paused {
+		state = "на паузе"
+	}
+	str := fmt.Sprintf("%s (%s, %s) %s, чаты %v", s.Name, s.Type, s.spec(), state, s.Chats)
+	if !s.Paused && !s.Next.IsZero() {
+		str += fmt.Sprintf("\nследующий запуск %s", s.Next.Format("02.01.2006 15:04"))
+	}
+	switch {
+	case s.LastRun.IsZero():
+		str += "\nеще не запускалась"
+	case s.LastErr != nil:
+		str += fmt.Sprintf("\nпоследний запуск %s: ошибка %s", s.LastRun.Format("02.01.2006 15:04"), s.LastErr)
+	default:
+		str += fmt.Sprintf("\nпоследний запуск %s: успешно", s.LastRun.Format("02.01.2006 15:04"))
+	}
+	return str
+}
+
+type Scheduler interface {
+	Handle(jobType string, handler Handler)
+	Start() error
+	List() []Status
+	Pause(name string) error
+	Resume(name string) error
+	Trigger(name string) error
+}
+
+type scheduler struct {
+	mu         sync.Mutex
+	cron       *cron.Cron
+	location   *time.Location
+	jobs       map[string]*scheduledJob
+	handlers   map[string]Handler
+	statusPath string
+}
+
+// savedStatus is the last run and the pause of a job kept on disk to survive restarts
+type savedStatus struct {
+	LastRun time.Time
+	LastErr string
+	Paused  bool
+}
+
+type scheduledJob struct {
+	status  Status
+	entryId cron.EntryID
+}
+
+// New schedules the jobs, the last runs are read from and saved to statusPath
+func New(jobs []Job, location *time.Location, statusPath string) (Scheduler, error) {
+	s := &scheduler{
+		cron:       cron.New(cron.WithLocation(location)),
+		location:   location,
+		jobs:       make(map[string]*scheduledJob, len(jobs)),
+		handlers:   make(map[string]Handler),
+		statusPath: statusPath,
+	}
+
+	for _, job := range jobs {
+		if _, ok := s.jobs[job.Name]; ok {
+			return nil, fmt.Errorf("задача %q объявлена дважды", job.Name)
+		}
+		if err := job.Validate(); err != nil {
+			return nil, err
+		}
+		s.jobs[job.Name] = &scheduledJob{status: Status{Job: job}}
+	}
+
+	saved, err := s.loadStatuses()
+	if err != nil {
+		return nil, err
+	}
+	for name, status := range saved {
+		if job, ok := s.jobs[name]; ok {
+			job.status.Paused = status.Paused
+			job.status.LastRun = status.LastRun.In(job.status.runLocation(location))
+			if status.LastErr != "" {
+				job.status.LastErr = errors.New(status.LastErr)
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *scheduler) loadStatuses() (map[string]savedStatus, error) {
+	saved := make(map[string]savedStatus)
+	data, err := os.ReadFile(s.statusPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return saved, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.statusPath, err)
+	}
+	return saved, nil
+}
+
+// saveStatuses expects the lock to be held
+func (s *scheduler) saveStatuses() error {
+	saved := make(map[string]savedStatus, len(s.jobs))
+	for name, job := range s.jobs {
+		status := savedStatus{LastRun: job.status.LastRun, Paused: job.status.Paused}
+		if job.status.LastErr != nil {
+			status.LastErr = job.status.LastErr.Error()
+		}
+		saved[name] = status
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.statusPath, data, 0644)
+}
+
+// runLocation is the job's own time zone or the scheduler's one
+func (j Job) runLocation(schedulerLocation *time.Location) *time.Location {
+	if location, ok := j.location(); ok {
+		return location
+	}
+	return schedulerLocation
+}
+
+func (s *scheduler) Handle(jobType string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+func (s *scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, job := range s.jobs {
+		if _, ok := s.handlers[job.status.Type]; !ok {
+			return fmt.Errorf("задача %q: нет обработчика для типа %q", name, job.status.Type)
+		}
+		name := name
+		entryId, err := s.cron.AddFunc(job.status.spec(), func() {
+			if !s.isPaused(name) {
+				s.run(name)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		job.entryId = entryId
+	}
+
+	s.cron.Start()
+	return nil
+}
+
+func (s *scheduler) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		status := job.status
+		status.Next = s.cron.Entry(job.entryId).Next.In(status.runLocation(s.location))
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+func (s *scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+func (s *scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+// Trigger runs the job right away, even a paused one
+func (s *scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJob, name)
+	}
+
+	go s.run(name)
+	return nil
+}
+
+func (s *scheduler) setPaused(name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJob, name)
+	}
+	job.status.Paused = paused
+	return s.saveStatuses()
+}
+
+func (s *scheduler) isPaused(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[name].status.Paused
+}
+
+func (s *scheduler) run(name string) {
+	s.mu.Lock()
+	job := s.jobs[name]
+	handler := s.handlers[job.status.Type]
+	chats := job.status.Chats
+	location := job.status.runLocation(s.location)
+	s.mu.Unlock()
+
+	startedAt := time.Now().In(location)
+	err := handler(chats)
+	if err != nil {
+		log.Printf("задача %s: %s", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.status.LastRun = startedAt
+	job.status.LastErr = err
+	if err = s.saveStatuses(); err != nil {
+		log.Printf("задача %s: %s", name, err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+type AnomalySettings struct {
+	BaselineDays int
+	DropRatio    float64
+	MinBaseline  float64
+}
+
+// DetectAnomalies compares the day's calls and orders per city, line and operator against the average of the preceding days,
+// holidays are left out of the baseline and are not compared with it. The operators are judged by operatorCalls
+// holding their outgoing calls as well, callHistory has only the incoming ones
+func (s *service) DetectAnomalies(callHistory, operatorCalls []entity.HistoryRecord, orders []entity.Orders, shifts []entity.Shift,
+	day time.Time) []entity.Anomaly {
+	day = truncateToDay(day)
+	baselineFrom := day.AddDate(0, 0, -s.anomalySettings.BaselineDays)
+	baselineDays := make(map[time.Time]bool, s.anomalySettings.BaselineDays)
+	for date := baselineFrom; date.Before(day); date = date.AddDate(0, 0, 1) {
+		if !s.workCalendar.IsHoliday(date) {
+			baselineDays[date] = true
+		}
+	}
+
+	callsPerCity := newDailyCounter()
+	callsPerLine := newDailyCounter()
+	callsPerOperator := newDailyCounter()
+	for _, record := range callHistory {
+		date := truncateToDay(record.Date)
+		if date.Before(baselineFrom) || date.After(day) {
+			continue
+		}
+		if city, ok := s.getCityByLine(record.LineNumber); ok {
+			callsPerCity.add(city, date)
+		}
+		callsPerLine.add(record.LineNumber, date)
+	}
+	for _, record := range operatorCalls {
+		date := truncateToDay(record.Date)
+		if date.Before(baselineFrom) || date.After(day) {
+			continue
+		}
+		if slices.Contains(s.operators, record.Operator) {
+			callsPerOperator.add(record.Operator, date)
+		}
+	}
+
+	ordersPerCity := newDailyCounter()
+	ordersPerOperator := newDailyCounter()
+	for _, order := range orders {
+		date := truncateToDay(order.Date)
+		if date.Before(baselineFrom) || date.After(day) {
+			continue
+		}
+		if city, ok := s.getOrderCity(order); ok {
+			ordersPerCity.add(city, date)
+		}
+		if slices.Contains(s.operators, order.Operator) {
+			ordersPerOperator.add(order.Operator, date)
+		}
+	}
+
+	anomalies := make([]entity.Anomaly, 0)
+	if !s.workCalendar.IsHoliday(day) {
+		anomalies = append(anomalies, s.compareWithBaseline("Звонки, город %s", callsPerCity, day, baselineDays)...)
+		anomalies = append(anomalies, s.compareWithBaseline("Звонки, линия %s", callsPerLine, day, baselineDays)...)
+		anomalies = append(anomalies, s.compareWithBaseline("Звонки, оператор %s", callsPerOperator, day, baselineDays)...)
+		anomalies = append(anomalies, s.compareWithBaseline("Заказы, город %s", ordersPerCity, day, baselineDays)...)
+		anomalies = append(anomalies, s.compareWithBaseline("Заказы, оператор %s", ordersPerOperator, day, baselineDays)...)
+	}
+
+	for operator, ordersByDay := range ordersPerOperator {
+		if ordersByDay[day] > 0 && callsPerOperator[operator][day] == 0 {
+			anomalies = append(anomalies, entity.Anomaly{
+				Kind:    entity.AnomalyOrdersWithoutCalls,
+				Subject: "Оператор " + operator,
+				Date:    day,
+				Value:   ordersByDay[day],
+			})
+		}
+	}
+	anomalies = append(anomalies, s.detectOffShiftCalls(callHistory, shifts, day)...)
+
+	return anomalies
+}
+
+func (s *service) compareWithBaseline(subjectLayout string, counter dailyCounter, day time.Time, baselineDays map[time.Time]bool) []entity.Anomaly {
+	anomalies := make([]entity.Anomaly, 0)
+	if len(baselineDays) == 0 {
+		return anomalies
+	}
+
+	for _, name := range counter.names() {
+		var baselineTotal int
+		for date, count := range counter[name] {
+			if baselineDays[date] {
+				baselineTotal += count
+			}
+		}
+		baseline := float64(baselineTotal) / float64(len(baselineDays))
+		if baseline < s.anomalySettings.MinBaseline {
+			continue
+		}
+
+		value := counter[name][day]
+		anomaly := entity.Anomaly{
+			Subject:  fmt.Sprintf(subjectLayout, name),
+			Date:     day,
+			Value:    value,
+			Baseline: baseline,
+		}
+		switch {
+		case value == 0:
+			anomaly.Kind = entity.AnomalyZeroActivity
+		case float64(value) < baseline*s.anomalySettings.DropRatio:
+			anomaly.Kind = entity.AnomalyDrop
+		default:
+			continue
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies
+}
+
+type dailyCounter map[string]map[time.Time]int
+
+func newDailyCounter() dailyCounter {
+	return make(dailyCounter)
+}
+
+func (c dailyCounter) add(name string, date time.Time) {
+	if _, ok := c[name]; !ok {
+		c[name] = make(map[time.Time]int)
+	}
+	c[name][date]++
+}
+
+func (c dailyCounter) names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func truncateToDay(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+}
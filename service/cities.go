@@ -0,0 +1,196 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CityLine assigns the calls to the lines matching the regexp to the city, a line matching several cities goes to
+// the one of the highest priority and, among equal priorities, to the one listed first in config.
+// The cities of a region are shown next to each other, CrmNames are the CRM cities.name values of the city's orders
+type CityLine struct {
+	City     string
+	Line     *regexp.Regexp
+	Priority int
+	Region   string
+	CrmNames []string
+}
+
+type CityLines []CityLine
+
+func (l CityLines) Validate() error {
+	regions := make(map[string]string)
+	crmCities := make(map[string]string)
+	for _, cityLine := range l {
+		if region, ok := regions[cityLine.City]; ok && region != cityLine.Region {
+			return fmt.Errorf("город %s указан в регионах %q и %q", cityLine.City, region, cityLine.Region)
+		}
+		regions[cityLine.City] = cityLine.Region
+		for _, crmName := range cityLine.CrmNames {
+			key := normalizeCrmName(crmName)
+			if city, ok := crmCities[key]; ok && city != cityLine.City {
+				return fmt.Errorf("город CRM %q отнесен и к %s, и к %s", crmName, city, cityLine.City)
+			}
+			crmCities[key] = cityLine.City
+		}
+	}
+	return nil
+}
+
+// cityIndex keeps the city lines in the matching order and the cities in the report order,
+// the config order with the cities of a region moved up to the region's first city
+type cityIndex struct {
+	lines     []CityLine
+	cities    []string
+	regions   []string
+	regionOf  map[string]string
+	crmCities map[string]string
+}
+
+func newCityIndex(cityLines CityLines) cityIndex {
+	index := cityIndex{
+		lines:     append([]CityLine{}, cityLines...),
+		cities:    make([]string, 0, len(cityLines)),
+		regions:   make([]string, 0),
+		regionOf:  make(map[string]string),
+		crmCities: make(map[string]string),
+	}
+	sort.SliceStable(index.lines, func(i, j int) bool { return index.lines[i].Priority > index.lines[j].Priority })
+
+	var hasRegions bool
+	for _, cityLine := range cityLines {
+		//cities without a region make a region of their own
+		region := cityLine.Region
+		if region == "" {
+			region = cityLine.City
+		} else {
+			hasRegions = true
+		}
+		index.regionOf[cityLine.City] = region
+		for _, crmName := range cityLine.CrmNames {
+			index.crmCities[normalizeCrmName(crmName)] = cityLine.City
+		}
+	}
+	for _, cityLine := range cityLines {
+		region := index.regionOf[cityLine.City]
+		if slices.Contains(index.regions, region) {
+			continue
+		}
+		index.regions = append(index.regions, region)
+		for _, regionCity := range cityLines {
+			if index.regionOf[regionCity.City] == region && !slices.Contains(index.cities, regionCity.City) {
+				index.cities = append(index.cities, regionCity.City)
+			}
+		}
+	}
+	if !hasRegions {
+		index.regions = nil
+	}
+	return index
+}
+
+func normalizeCrmName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// getOrderCity attributes the order to exactly one city, the one its CRM city is mapped to,
+// otherwise the first city in the report order whose name the CRM city contains
+func (s *service) getOrderCity(order entity.Orders) (string, bool) {
+	if city, ok := s.crmCities[normalizeCrmName(order.City)]; ok {
+		return city, true
+	}
+	for _, city := range s.cities {
+		if strings.Contains(normalizeCrmName(order.City), strings.ToLower(city)) {
+			return city, true
+		}
+	}
+	return "", false
+}
+
+// getOrderCityCandidates returns the cities whose names the unmapped CRM city contains
+func (s *service) getOrderCityCandidates(order entity.Orders) []string {
+	cities := make([]string, 0, 1)
+	if _, ok := s.crmCities[normalizeCrmName(order.City)]; ok {
+		return cities
+	}
+	for _, city := range s.cities {
+		if strings.Contains(normalizeCrmName(order.City), strings.ToLower(city)) {
+			cities = append(cities, city)
+		}
+	}
+	return cities
+}
+
+func (s *service) getRegionByLine(lineNumber string) (string, bool) {
+	city, ok := s.getCityByLine(lineNumber)
+	return s.regionOf[city], ok
+}
+
+func (s *service) getOrderRegion(order entity.Orders) (string, bool) {
+	city, ok := s.getOrderCity(order)
+	return s.regionOf[city], ok
+}
+
+func (s *service) getCityByLine(lineNumber string) (string, bool) {
+	for _, cityLine := range s.lines {
+		if cityLine.Line.MatchString(lineNumber) {
+			return cityLine.City, true
+		}
+	}
+	return "", false
+}
+
+// getCitiesByLine returns all the cities matching the line in the matching order
+func (s *service) getCitiesByLine(lineNumber string) []string {
+	cities := make([]string, 0, 1)
+	for _, cityLine := range s.lines {
+		if cityLine.Line.MatchString(lineNumber) && !slices.Contains(cities, cityLine.City) {
+			cities = append(cities, cityLine.City)
+		}
+	}
+	return cities
+}
+
+// GetLineMatchReport counts the period's calls to the lines matching no city or several cities
+func (s *service) GetLineMatchReport(callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.LineMatchReport {
+	callsPerLine := make(map[string]int)
+	for _, record := range callHistory {
+		if s.isDateBetween(dateFrom, dateTo, record.Date) {
+			callsPerLine[record.LineNumber]++
+		}
+	}
+
+	report := entity.LineMatchReport{
+		DateFrom:  dateFrom,
+		DateTo:    dateTo,
+		Unmatched: make([]entity.LineCalls, 0),
+		Ambiguous: make([]entity.LineCalls, 0),
+	}
+	for lineNumber, calls := range callsPerLine {
+		cities := s.getCitiesByLine(lineNumber)
+		switch len(cities) {
+		case 0:
+			report.Unmatched = append(report.Unmatched, entity.LineCalls{LineNumber: lineNumber, Calls: calls})
+		case 1:
+		default:
+			report.Ambiguous = append(report.Ambiguous, entity.LineCalls{LineNumber: lineNumber, Matches: cities, Calls: calls})
+		}
+	}
+	sortLineCalls(report.Unmatched)
+	sortLineCalls(report.Ambiguous)
+	return report
+}
+
+func sortLineCalls(lines []entity.LineCalls) {
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Calls != lines[j].Calls {
+			return lines[i].Calls > lines[j].Calls
+		}
+		return lines[i].LineNumber < lines[j].LineNumber
+	})
+}
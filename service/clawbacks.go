@@ -0,0 +1,109 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// GetClawbacks picks the paid orders that have been cancelled or returned since
+func (s *service) GetClawbacks(paid []entity.PaidOrder, statuses map[uint]string) []entity.Clawback {
+	clawbacks := make([]entity.Clawback, 0)
+	for _, order := range paid {
+		switch status := statuses[order.Id]; status {
+		case entity.OrderCancelled, entity.OrderReturned:
+			clawbacks = append(clawbacks, entity.Clawback{PaidOrder: order, Status: status})
+		}
+	}
+	sort.Slice(clawbacks, func(i, j int) bool { return clawbacks[i].Date.Before(clawbacks[j].Date) })
+	return clawbacks
+}
+
+// applyClawbacks deducts the clawbacks from the operators' payments, the ones of operators missing from the report
+// are left for a later report
+func (s *service) applyClawbacks(operatorReports []entity.OperatorReport, clawbacks []entity.Clawback) (applied []entity.Clawback) {
+	applied = make([]entity.Clawback, 0, len(clawbacks))
+	for _, clawback := range clawbacks {
+		i := slices.IndexFunc(operatorReports, func(report entity.OperatorReport) bool { return report.Name == clawback.Operator })
+		if i < 0 {
+			continue
+		}
+		operatorReports[i].Clawback += clawback.Amount
+		operatorReports[i].SummaryPayment -= clawback.Amount
+		if operatorReports[i].OrdersCount > 0 {
+			operatorReports[i].PricePerOrder = operatorReports[i].SummaryPayment / float64(operatorReports[i].OrdersCount)
+		}
+		applied = append(applied, clawback)
+	}
+	return applied
+}
+
+// getPaidOrders lists the operators' orders of the report with what each earned the operator, to claw it back later
+func (s *service) getPaidOrders(orders []entity.Orders, operatorReports []entity.OperatorReport, dateFrom, dateTo time.Time) []entity.PaidOrder {
+	bonusPerOrder := make(map[string]float64, len(operatorReports))
+	for _, report := range operatorReports {
+		if report.OrdersCount > 0 && slices.Contains(s.operators, report.Name) {
+			bonusPerOrder[report.Name] = report.Bonus / float64(report.OrdersCount)
+		}
+	}
+
+	paid := make([]entity.PaidOrder, 0, len(orders))
+	for _, order := range orders {
+		bonus, ok := bonusPerOrder[order.Operator]
+		if !ok {
+			continue
+		}
+		paid = append(paid, entity.PaidOrder{
+			Id:       order.Id,
+			Operator: order.Operator,
+			Date:     order.Date,
+			Amount:   math.Round((s.orderPay(order)*s.feeMultiplier(order.Date)+bonus)*100) / 100,
+			DateFrom: dateFrom,
+			DateTo:   dateTo,
+		})
+	}
+	return paid
+}
+
+// settlePendingOrders pays the order fee for the pending orders that have turned counted and returns the orders still waiting,
+// the cancelled ones are dropped. Orders of operators missing from the report wait for a later one
+func (s *service) settlePendingOrders(operatorReports []entity.OperatorReport, pending []entity.PendingOrder,
+	orders []entity.Orders) (late []entity.LateOrder, waiting []entity.PendingOrder) {
+	//the period's own counted orders are paid the usual way when the period is computed again
+	seen := make(map[uint]bool, len(orders)+len(pending))
+	for _, order := range orders {
+		seen[order.Id] = true
+	}
+
+	late = make([]entity.LateOrder, 0)
+	waiting = make([]entity.PendingOrder, 0, len(pending))
+	for _, order := range pending {
+		if seen[order.Id] || !slices.Contains(s.operators, order.Operator) {
+			continue
+		}
+		seen[order.Id] = true
+
+		switch order.Status {
+		case entity.OrderPending:
+			waiting = append(waiting, order)
+		case entity.OrderCounted:
+			i := slices.IndexFunc(operatorReports, func(report entity.OperatorReport) bool { return report.Name == order.Operator })
+			if i < 0 {
+				waiting = append(waiting, order)
+				continue
+			}
+			orderPay, multiplier := s.orderPay(order.Orders), s.feeMultiplier(order.Date)
+			amount := math.Round(orderPay*multiplier*100) / 100
+			operatorReports[i].Salary += amount
+			operatorReports[i].HolidayPay += orderPay * (multiplier - 1)
+			operatorReports[i].SummaryPayment += amount
+			if operatorReports[i].OrdersCount > 0 {
+				operatorReports[i].PricePerOrder = operatorReports[i].SummaryPayment / float64(operatorReports[i].OrdersCount)
+			}
+			late = append(late, entity.LateOrder{PendingOrder: order, Amount: amount})
+		}
+	}
+	return late, waiting
+}
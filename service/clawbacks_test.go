@@ -0,0 +1,46 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"testing"
+	"time"
+)
+
+func TestSettlePendingOrders(t *testing.T) {
+	pastFrom := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	pastTo := pastFrom.AddDate(0, 0, 6)
+	pending := func(id uint, operator, status string) entity.PendingOrder {
+		return entity.PendingOrder{
+			Orders:   entity.Orders{Id: id, Operator: operator, Status: status, Date: pastFrom.AddDate(0, 0, 2)},
+			DateFrom: pastFrom,
+			DateTo:   pastTo,
+		}
+	}
+	s := &service{operators: []string{"Анна", "Иван"}, orderFee: 150}
+
+	operatorReports := []entity.OperatorReport{{Name: "Анна", Salary: 300, SummaryPayment: 300, OrdersCount: 2, PricePerOrder: 150}}
+	orders := []entity.Orders{{Id: 5, Operator: "Анна"}}
+	late, waiting := s.settlePendingOrders(operatorReports, []entity.PendingOrder{
+		pending(1, "Анна", entity.OrderCounted),
+		pending(2, "Анна", entity.OrderPending),
+		pending(3, "Анна", entity.OrderCancelled),
+		pending(4, "Иван", entity.OrderCounted),
+		pending(5, "Анна", entity.OrderCounted),
+		pending(6, "Руководитель", entity.OrderPending),
+		pending(1, "Анна", entity.OrderCounted),
+	}, orders)
+
+	if len(late) != 1 || late[0].Id != 1 || late[0].Amount != 150 {
+		t.Errorf("late orders = %+v, want order 1 paid 150", late)
+	}
+	var waitingIds []uint
+	for _, order := range waiting {
+		waitingIds = append(waitingIds, order.Id)
+	}
+	if len(waitingIds) != 2 || waitingIds[0] != 2 || waitingIds[1] != 4 {
+		t.Errorf("waiting orders = %v, want [2 4]", waitingIds)
+	}
+	if report := operatorReports[0]; report.Salary != 450 || report.SummaryPayment != 450 || report.PricePerOrder != 225 {
+		t.Errorf("operator report = %+v, want the late order added to the pay", report)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"sort"
+)
+
+// CommissionTiers maps the lowest order amount of a tier to the percent of the amount paid for the order,
+// without tiers every order is paid the fixed order fee
+type CommissionTiers map[float64]float64
+
+func (t CommissionTiers) Validate() error {
+	if _, ok := t[0]; len(t) > 0 && !ok {
+		return fmt.Errorf("ступени комиссии должны начинаться с заказов от 0")
+	}
+	for threshold, percent := range t {
+		if threshold < 0 {
+			return fmt.Errorf("нижняя граница ступени комиссии %g не может быть отрицательной", threshold)
+		}
+		if percent <= 0 || percent > 100 {
+			return fmt.Errorf("комиссия ступени от %g должна быть от 0 до 100%%, а не %g", threshold, percent)
+		}
+	}
+	return nil
+}
+
+// percent returns the commission of the highest tier the amount reaches
+func (t CommissionTiers) percent(amount float64) float64 {
+	thresholds := make([]float64, 0, len(t))
+	for threshold := range t {
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Float64s(thresholds)
+
+	var percent float64
+	for _, threshold := range thresholds {
+		if amount < threshold {
+			break
+		}
+		percent = t[threshold]
+	}
+	return percent
+}
+
+// orderPay returns the operator's pay for the order before the holiday multipliers
+func (s *service) orderPay(order entity.Orders) float64 {
+	if len(s.commissionTiers) == 0 {
+		return s.orderFee
+	}
+	return order.Amount * s.commissionTiers.percent(order.Amount) / 100
+}
+
+// getOperatorsPay sums the pay for the orders per operator, holidayPay is the part due to the holiday multipliers
+func (s *service) getOperatorsPay(orders []entity.Orders) (pay, holidayPay map[string]float64) {
+	pay = make(map[string]float64)
+	holidayPay = make(map[string]float64)
+	for _, order := range orders {
+		orderPay := s.orderPay(order)
+		multiplier := s.feeMultiplier(order.Date)
+		pay[order.Operator] += orderPay * multiplier
+		holidayPay[order.Operator] += orderPay * (multiplier - 1)
+	}
+	return pay, holidayPay
+}
+
+// getRevenue sums the order amounts per operator and over all orders
+func (s *service) getRevenue(orders []entity.Orders) (perOperator map[string]float64, total float64) {
+	perOperator = make(map[string]float64)
+	for _, order := range orders {
+		perOperator[order.Operator] += order.Amount
+		total += order.Amount
+	}
+	return perOperator, total
+}
+
+func calculateAverageOrderValue(revenue float64, ordersCount int) (averageOrderValue float64) {
+	if ordersCount > 0 {
+		averageOrderValue = revenue / float64(ordersCount)
+	}
+	return averageOrderValue
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const noMoreExpenses = "нет"
+
+// RecurringExpense is paid every period, prompted ones are asked for the actual amount when the report is made
+type RecurringExpense struct {
+	Name   string
+	Amount float64
+	Prompt bool
+}
+
+// GetDefaultExpenses returns the recurring expenses with their configured amounts, used when nobody can be asked
+func (s *service) GetDefaultExpenses() []entity.ExpenseItem {
+	expenses := make([]entity.ExpenseItem, 0, len(s.recurringExpenses))
+	for _, expense := range s.recurringExpenses {
+		expenses = append(expenses, entity.ExpenseItem{Name: expense.Name, Amount: expense.Amount})
+	}
+	return expenses
+}
+
+// askExpenses asks the amounts of the prompted recurring expenses and then any ad-hoc expense lines
+func (s *service) askExpenses(readWriter io.ReadWriter) []entity.ExpenseItem {
+	expenses := make([]entity.ExpenseItem, 0, len(s.recurringExpenses))
+	for _, expense := range s.recurringExpenses {
+		amount := expense.Amount
+		if expense.Prompt && !debug {
+			amount = s.getFloat64FromIO(readWriter, fmt.Sprintf("Сколько заплатили за «%s»?", expense.Name))
+		}
+		expenses = append(expenses, entity.ExpenseItem{Name: expense.Name, Amount: amount})
+	}
+	if debug {
+		return expenses
+	}
+
+	for {
+		_, _ = readWriter.Write([]byte(fmt.Sprintf("Дополнительные расходы: отправьте \"название сумма\" по одной статье или \"%s\"", noMoreExpenses)))
+		answer := strings.TrimSpace(getSrtFromReader(readWriter))
+		if strings.EqualFold(answer, noMoreExpenses) {
+			return expenses
+		}
+		expense, err := entity.ParseExpenseItem(answer)
+		if err != nil {
+			_, _ = readWriter.Write([]byte(err.Error()))
+			continue
+		}
+		expenses = append(expenses, expense)
+	}
+}
+
+func (s *service) calculateTotalExpenses(departmentPayment float64, expenses []entity.ExpenseItem) float64 {
+	totalExpenses := departmentPayment
+	for _, expense := range expenses {
+		totalExpenses += expense.Amount
+	}
+	return totalExpenses
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/period"
+	"fmt"
+	"time"
+)
+
+// HolidayFees multiplies the order fee for the orders taken on holidays and short days, 1 keeps the usual fee
+type HolidayFees struct {
+	HolidayMultiplier  float64
+	ShortDayMultiplier float64
+}
+
+func (f HolidayFees) Validate() error {
+	if f.HolidayMultiplier <= 0 || f.ShortDayMultiplier <= 0 {
+		return fmt.Errorf("множители оплаты за праздники должны быть положительными")
+	}
+	return nil
+}
+
+func (s *service) feeMultiplier(date time.Time) float64 {
+	day, ok := s.workCalendar.Day(date)
+	switch {
+	case !ok:
+		return 1
+	case day.Kind == period.Holiday:
+		return s.holidayFees.HolidayMultiplier
+	case day.Kind == period.ShortDay:
+		return s.holidayFees.ShortDayMultiplier
+	default:
+		return 1
+	}
+}
+
+// getHolidayExtraOrders counts the orders' extra weight due to the holiday multipliers over all orders
+func (s *service) getHolidayExtraOrders(orders []entity.Orders) (total float64) {
+	for _, order := range orders {
+		total += s.feeMultiplier(order.Date) - 1
+	}
+	return total
+}
+
+func (s *service) getDayLabel(date time.Time) string {
+	day, _ := s.workCalendar.Day(date)
+	return day.Label()
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const phoneSignificantDigits = 10
+
+func (s *service) GetJourneyReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.JourneyReport {
+	callsByPhone := getCallsByPhone(callHistory)
+
+	journeys := make(map[string]*entity.CityJourney)
+	callerCities := make(map[string]string)
+	newCallers := make(map[string]bool)
+
+	for phone, calls := range callsByPhone {
+		var callsInPeriod []entity.HistoryRecord
+		var calledBefore bool
+		for _, call := range calls {
+			if call.Date.Before(dateFrom) {
+				calledBefore = true
+			} else if s.isDateBetween(dateFrom, dateTo, call.Date) {
+				callsInPeriod = append(callsInPeriod, call)
+			}
+		}
+		if len(callsInPeriod) == 0 {
+			continue
+		}
+
+		city, ok := s.getCityByLine(callsInPeriod[0].LineNumber)
+		if !ok {
+			continue
+		}
+		journey, ok := journeys[city]
+		if !ok {
+			journey = &entity.CityJourney{City: city}
+			journeys[city] = journey
+		}
+
+		journey.Callers++
+		if len(callsInPeriod) > 1 {
+			journey.RepeatCallers++
+		}
+		if calledBefore {
+			journey.ReturningCallers++
+		} else {
+			journey.NewCallers++
+		}
+		callerCities[phone] = city
+		newCallers[phone] = !calledBefore
+	}
+
+	report := entity.JourneyReport{
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	}
+
+	var contactsBeforeOrders int
+	for _, linkedOrder := range s.linkOrdersToCalls(orders, callHistory) {
+		if !linkedOrder.Linked {
+			report.UnlinkedOrders++
+			continue
+		}
+
+		report.LinkedOrders++
+		contactsBeforeOrders += linkedOrder.ContactsBefore
+		phone := normalizePhone(linkedOrder.Order.Phone)
+		if city, ok := callerCities[phone]; ok {
+			if newCallers[phone] {
+				journeys[city].NewCallersOrders++
+			} else {
+				journeys[city].ReturningCallersOrders++
+			}
+		}
+	}
+	if report.LinkedOrders > 0 {
+		report.AvgContactsBeforeOrder = float64(contactsBeforeOrders) / float64(report.LinkedOrders)
+	}
+
+	report.CityJourneys = make([]entity.CityJourney, 0, len(journeys))
+	for _, journey := range journeys {
+		journey.NewConversion = s.calculateConversion(journey.NewCallers, journey.NewCallersOrders)
+		journey.ReturningConversion = s.calculateConversion(journey.ReturningCallers, journey.ReturningCallersOrders)
+		report.CityJourneys = append(report.CityJourneys, *journey)
+	}
+	sort.Slice(report.CityJourneys, func(i, j int) bool { return report.CityJourneys[i].Callers > report.CityJourneys[j].Callers })
+
+	return report
+}
+
+func getCallsByPhone(callHistory []entity.HistoryRecord) map[string][]entity.HistoryRecord {
+	callsByPhone := make(map[string][]entity.HistoryRecord)
+	for _, record := range callHistory {
+		phone := normalizePhone(record.Abonent)
+		callsByPhone[phone] = append(callsByPhone[phone], record)
+	}
+	return callsByPhone
+}
+
+func normalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) > phoneSignificantDigits {
+		digits = digits[len(digits)-phoneSignificantDigits:]
+	}
+	return digits
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"sort"
+	"time"
+)
+
+func (s *service) GetLinkageReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.LinkageReport {
+	report := entity.LinkageReport{
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	}
+
+	ordersPerCity := make(map[string]int)
+	ordersPerLine := make(map[string]int)
+	for _, linkedOrder := range s.linkOrdersToCalls(orders, callHistory) {
+		if !linkedOrder.Linked {
+			report.UnlinkedOrdersCount++
+			continue
+		}
+		report.LinkedOrdersCount++
+		ordersPerLine[linkedOrder.Call.LineNumber]++
+		if city, ok := s.getCityByLine(linkedOrder.Call.LineNumber); ok {
+			ordersPerCity[city]++
+		}
+	}
+
+	inPeriod := func(record entity.HistoryRecord) bool {
+		return s.isDateBetween(dateFrom, dateTo, record.Date)
+	}
+	uniqCallsPerCity := s.countUniqCalls(callHistory, dateFrom, inPeriod, s.getCityByLine)
+	uniqCallsPerLine := s.countUniqCalls(callHistory, dateFrom, inPeriod, func(lineNumber string) (string, bool) {
+		return lineNumber, true
+	})
+
+	report.CityStatistics = s.makeLinkedStatistics(uniqCallsPerCity, ordersPerCity)
+	report.LineStatistics = s.makeLinkedStatistics(uniqCallsPerLine, ordersPerLine)
+
+	return report
+}
+
+// linkOrdersToCalls links every order to the last call from the same phone within the linkage window before the order
+func (s *service) linkOrdersToCalls(orders []entity.Orders, callHistory []entity.HistoryRecord) []entity.LinkedOrder {
+	callsByPhone := getCallsByPhone(callHistory)
+
+	linkedOrders := make([]entity.LinkedOrder, 0, len(orders))
+	for _, order := range orders {
+		linkedOrder := entity.LinkedOrder{Order: order}
+		if phone := normalizePhone(order.Phone); phone != "" {
+			windowStart := order.Date.AddDate(0, 0, -s.linkageWindowDays)
+			for _, call := range callsByPhone[phone] {
+				if call.Date.Before(windowStart) || call.Date.After(order.Date) {
+					continue
+				}
+				linkedOrder.Call = call
+				linkedOrder.Linked = true
+				linkedOrder.ContactsBefore++
+			}
+		}
+		linkedOrders = append(linkedOrders, linkedOrder)
+	}
+
+	return linkedOrders
+}
+
+func (s *service) makeLinkedStatistics(uniqCalls, orders map[string]int) []entity.LinkedStatistic {
+	names := make(map[string]struct{})
+	for name := range uniqCalls {
+		names[name] = struct{}{}
+	}
+	for name := range orders {
+		names[name] = struct{}{}
+	}
+
+	statistics := make([]entity.LinkedStatistic, 0, len(names))
+	for name := range names {
+		statistics = append(statistics, entity.LinkedStatistic{
+			Name:        name,
+			UniqCalls:   uniqCalls[name],
+			OrdersCount: orders[name],
+			Conversion:  s.calculateConversion(uniqCalls[name], orders[name]),
+		})
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		if statistics[i].UniqCalls != statistics[j].UniqCalls {
+			return statistics[i].UniqCalls > statistics[j].UniqCalls
+		}
+		return statistics[i].Name < statistics[j].Name
+	})
+
+	return statistics
+}
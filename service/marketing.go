@@ -0,0 +1,140 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+)
+
+const unknownSource = "Без источника"
+
+// SourceLine attributes the lines matching the regexp to the marketing source, a line matching several sources goes to
+// the one of the highest priority and, among equal priorities, to the one listed first in config
+type SourceLine struct {
+	Source   string
+	Line     *regexp.Regexp
+	Priority int
+}
+
+// sortSourceLines orders the source lines for matching and lists the sources in config order
+func sortSourceLines(sourceLines []SourceLine) (sorted []SourceLine, sources []string) {
+	sorted = append([]SourceLine{}, sourceLines...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority > sorted[j].Priority })
+	sources = make([]string, 0, len(sourceLines))
+	for _, sourceLine := range sourceLines {
+		if !slices.Contains(sources, sourceLine.Source) {
+			sources = append(sources, sourceLine.Source)
+		}
+	}
+	return sorted, sources
+}
+
+func (s *service) GetMarketingReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.MarketingReport {
+	uniqCallsTotal := s.countUniqCalls(callHistory, dateFrom, func(record entity.HistoryRecord) bool {
+		return s.isDateBetween(dateFrom, dateTo, record.Date)
+	}, s.getSourceByLine)
+	uniqCallsReceived := s.countUniqCalls(callHistory, dateFrom, func(record entity.HistoryRecord) bool {
+		return s.isDateBetween(dateFrom, dateTo, record.Date) && record.Operator != ""
+	}, s.getSourceByLine)
+
+	ordersPerSource := make(map[string]int)
+	for _, linkedOrder := range s.linkOrdersToCalls(orders, callHistory) {
+		source := unknownSource
+		if linkedOrder.Linked {
+			source, _ = s.getSourceByLine(linkedOrder.Call.LineNumber)
+		}
+		ordersPerSource[source]++
+	}
+
+	sourceNames := append(slices.Clip(s.marketingSources), unknownSource)
+
+	sourceStatistics := make([]entity.SourceStatistic, 0, len(sourceNames))
+	for _, source := range sourceNames {
+		ordersCount := ordersPerSource[source]
+		spend := s.calculateAdSpend(source, dateFrom, dateTo)
+		var costPerOrder float64
+		if ordersCount > 0 {
+			costPerOrder = spend / float64(ordersCount)
+		}
+		sourceStatistics = append(sourceStatistics, entity.SourceStatistic{
+			Source:            source,
+			UniqCallsTotal:    uniqCallsTotal[source],
+			UniqCallsReceived: uniqCallsReceived[source],
+			OrdersCount:       ordersCount,
+			Conversion:        s.calculateConversion(uniqCallsTotal[source], ordersCount),
+			Spend:             spend,
+			CostPerOrder:      costPerOrder,
+		})
+	}
+
+	return entity.MarketingReport{
+		DateFrom:         dateFrom,
+		DateTo:           dateTo,
+		SourceStatistics: sourceStatistics,
+		AmbiguousLines:   s.getAmbiguousSourceLines(callHistory, dateFrom, dateTo),
+	}
+}
+
+func (s *service) getSourceByLine(lineNumber string) (string, bool) {
+	for _, sourceLine := range s.sourceLines {
+		if sourceLine.Line.MatchString(lineNumber) {
+			return sourceLine.Source, true
+		}
+	}
+	return unknownSource, true
+}
+
+// getAmbiguousSourceLines counts the period's calls to the lines matching several sources
+func (s *service) getAmbiguousSourceLines(callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) []entity.LineCalls {
+	callsPerLine := make(map[string]int)
+	for _, record := range callHistory {
+		if s.isDateBetween(dateFrom, dateTo, record.Date) {
+			callsPerLine[record.LineNumber]++
+		}
+	}
+
+	ambiguous := make([]entity.LineCalls, 0)
+	for lineNumber, calls := range callsPerLine {
+		sources := make([]string, 0, 1)
+		for _, sourceLine := range s.sourceLines {
+			if sourceLine.Line.MatchString(lineNumber) && !slices.Contains(sources, sourceLine.Source) {
+				sources = append(sources, sourceLine.Source)
+			}
+		}
+		if len(sources) > 1 {
+			ambiguous = append(ambiguous, entity.LineCalls{LineNumber: lineNumber, Matches: sources, Calls: calls})
+		}
+	}
+	sortLineCalls(ambiguous)
+	return ambiguous
+}
+
+// calculateAdSpend prorates every configured spend by the number of its days falling into the period
+func (s *service) calculateAdSpend(source string, dateFrom, dateTo time.Time) (spend float64) {
+	for _, adSpend := range s.adSpends {
+		if adSpend.Source != source {
+			continue
+		}
+		overlapFrom, overlapTo := adSpend.DateFrom, adSpend.DateTo
+		if dateFrom.After(overlapFrom) {
+			overlapFrom = dateFrom
+		}
+		if dateTo.Before(overlapTo) {
+			overlapTo = dateTo
+		}
+		if overlapTo.Before(overlapFrom) {
+			continue
+		}
+		spendDays := daysInclusive(adSpend.DateFrom, adSpend.DateTo)
+		spend += adSpend.Amount * float64(daysInclusive(overlapFrom, overlapTo)) / float64(spendDays)
+	}
+	return spend
+}
+
+// daysInclusive rounds the hours, days around DST changes are not 24 hours long
+func daysInclusive(dateFrom, dateTo time.Time) int {
+	return int(math.Round(dateTo.Sub(dateFrom).Hours()/24)) + 1
+}
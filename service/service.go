@@ -2,11 +2,11 @@ package service
 
 import (
 	"callCenterReportMaker/entity"
+	"callCenterReportMaker/period"
 	"fmt"
 	"io"
 	"log"
 	"math"
-	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -14,7 +14,11 @@ import (
 	"time"
 )
 
-const debug = false
+const (
+	debug        = false
+	bossOrderFee = 20
+	totalCity    = "Итого"
+)
 
 type Service interface {
 	GetUniqTotalCallsCountPerCity(historyRecords []entity.HistoryRecord, dateFrom, dateTo time.Time) map[string]int
@@ -24,48 +28,87 @@ type Service interface {
 	GetWeeklyReport(callsByOperators []entity.DatabaseStatistic,
 		orders []entity.Orders,
 		callHistory []entity.HistoryRecord,
+		shifts []entity.Shift,
+		clawbacks []entity.Clawback,
+		pending []entity.PendingOrder,
 		dateFrom, dateTo time.Time,
+		inputs entity.ReportInputs,
 		readWriter io.ReadWriter) entity.WeeklyReport
+	GetDefaultExpenses() []entity.ExpenseItem
+	GetClawbacks(paid []entity.PaidOrder, statuses map[uint]string) []entity.Clawback
+	GetGradeAttainment(total entity.DatabaseStatistic, daysPassed, daysLeft int) entity.GradeAttainment
+	GetJourneyReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.JourneyReport
+	GetMarketingReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.MarketingReport
+	GetLinkageReport(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.LinkageReport
+	DetectAnomalies(callHistory, operatorCalls []entity.HistoryRecord, orders []entity.Orders, shifts []entity.Shift, day time.Time) []entity.Anomaly
+	GetCityStatistics(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) []entity.CityStatistic
+	ValidateData(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) []entity.DataIssue
+	GetLineMatchReport(callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) entity.LineMatchReport
 }
 
-func New(citiesLineMap map[string]*regexp.Regexp, operatorsList []string, bonusMap map[float64]float64, orderCost, personalConversionGrade float64) Service {
+func New(cityLines CityLines, operatorsList []string, bonusMap map[float64]float64, orderCost, personalConversionGrade float64,
+	uniqPolicy UniquenessPolicy, sourceLines []SourceLine, adSpends []entity.AdSpend, linkageWindowDays int,
+	anomalySettings AnomalySettings, recurringExpenses []RecurringExpense, workCalendar period.WorkCalendar, holidayFees HolidayFees,
+	talkTimeSettings TalkTimeSettings, commissionTiers CommissionTiers) Service {
+	sortedSourceLines, sources := sortSourceLines(sourceLines)
 	return &service{
-		citiesAndLines:     citiesLineMap,
+		cityIndex:          newCityIndex(cityLines),
 		operators:          operatorsList,
 		motivationMap:      bonusMap,
 		orderFee:           orderCost,
 		minConversionGrade: personalConversionGrade,
+		uniquenessPolicy:   uniqPolicy,
+		sourceLines:        sortedSourceLines,
+		marketingSources:   sources,
+		adSpends:           adSpends,
+		linkageWindowDays:  linkageWindowDays,
+		anomalySettings:    anomalySettings,
+		recurringExpenses:  recurringExpenses,
+		workCalendar:       workCalendar,
+		holidayFees:        holidayFees,
+		talkTimeSettings:   talkTimeSettings,
+		commissionTiers:    commissionTiers,
 	}
 }
 
 type service struct {
-	citiesAndLines     map[string]*regexp.Regexp
+	cityIndex
 	operators          []string
 	motivationMap      map[float64]float64
 	orderFee           float64
 	minConversionGrade float64
+	uniquenessPolicy   UniquenessPolicy
+	sourceLines        []SourceLine
+	marketingSources   []string
+	adSpends           []entity.AdSpend
+	linkageWindowDays  int
+	anomalySettings    AnomalySettings
+	recurringExpenses  []RecurringExpense
+	workCalendar       period.WorkCalendar
+	holidayFees        HolidayFees
+	talkTimeSettings   TalkTimeSettings
+	commissionTiers    CommissionTiers
 }
 
 func (s *service) GetUniqTotalCallsCountPerCity(historyRecords []entity.HistoryRecord, dateFrom, dateTo time.Time) map[string]int {
-	return s.getUniqCallsWithFilter(historyRecords, func(record entity.HistoryRecord) bool {
+	return s.getUniqCallsWithFilter(historyRecords, dateFrom, func(record entity.HistoryRecord) bool {
 		return s.isDateBetween(dateFrom, dateTo, record.Date)
 	})
 }
 
 func (s *service) GetUniqReceivedCallsCountPerCity(historyRecords []entity.HistoryRecord, dateFrom, dateTo time.Time) map[string]int {
-	return s.getUniqCallsWithFilter(historyRecords, func(record entity.HistoryRecord) bool {
+	return s.getUniqCallsWithFilter(historyRecords, dateFrom, func(record entity.HistoryRecord) bool {
 		return s.isDateBetween(dateFrom, dateTo, record.Date) && record.Operator != ""
 	})
 }
 
 func (s *service) GetUniqReceivedCallsByOperator(historyRecords []entity.HistoryRecord, dateFrom, dateTo time.Time) map[time.Time]map[string]int {
-	uniqCallsMap := make(map[string]struct{})
+	uniqCallsTracker := s.newUniqCallsTracker(dateFrom)
 
 	result := make(map[time.Time]map[string]int)
 
 	for _, record := range historyRecords {
-		if _, recordExists := uniqCallsMap[record.Abonent]; !recordExists {
-			uniqCallsMap[record.Abonent] = struct{}{}
+		if uniqCallsTracker.isUniq(record) {
 			if s.isDateBetween(dateFrom, dateTo, record.Date) && slices.Contains(s.operators, record.Operator) {
 				if _, dateExists := result[record.Date]; !dateExists {
 					result[record.Date] = make(map[string]int)
@@ -79,18 +122,11 @@ func (s *service) GetUniqReceivedCallsByOperator(historyRecords []entity.History
 }
 
 func (s *service) GetOrdersPerCity(orders []entity.Orders) map[string]int {
-	cities := make([]string, 0, len(s.citiesAndLines))
-	for city := range s.citiesAndLines {
-		cities = append(cities, city)
-	}
-
 	ordersPerCity := make(map[string]int)
 
 	for _, order := range orders {
-		for _, city := range cities {
-			if strings.Contains(strings.ToLower(order.City), strings.ToLower(city)) {
-				ordersPerCity[city]++
-			}
+		if city, ok := s.getOrderCity(order); ok {
+			ordersPerCity[city]++
 		}
 	}
 	return ordersPerCity
@@ -138,99 +174,194 @@ func (s *service) GetDatabaseStatistic(callsByOperators []entity.DatabaseStatist
 }
 
 func (s *service) GetWeeklyReport(callsByOperators []entity.DatabaseStatistic, orders []entity.Orders,
-	callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time, readWriter io.ReadWriter) entity.WeeklyReport {
+	callHistory []entity.HistoryRecord, shifts []entity.Shift, clawbacks []entity.Clawback, pending []entity.PendingOrder, dateFrom, dateTo time.Time,
+	inputs entity.ReportInputs, readWriter io.ReadWriter) entity.WeeklyReport {
 
 	databaseStatistics := s.GetDatabaseStatistic(callsByOperators, orders)
 	totalOrdersCount := databaseStatistics[len(databaseStatistics)-1].OrdersCount
-	departmentBonus, personalBonusPerOrder := s.calculateBonus(databaseStatistics, readWriter)
-	operatorReports := s.calculateOperatorsReport(databaseStatistics, departmentBonus, personalBonusPerOrder)
+	talkTimePerOperator, _ := s.getTalkTimeStatistics(callHistory, dateFrom, dateTo, s.getCityByLine)
+	departmentBonus, bonusPerOrder, personalBonusPerOrder := s.calculateBonus(databaseStatistics, talkTimePerOperator, inputs, readWriter)
+	operatorsPay, holidayPay := s.getOperatorsPay(orders)
+	revenuePerOperator, totalRevenue := s.getRevenue(orders)
+	operatorReports := s.calculateOperatorsReport(databaseStatistics, departmentBonus, personalBonusPerOrder, operatorsPay, holidayPay,
+		s.getHolidayExtraOrders(orders), talkTimePerOperator, revenuePerOperator)
+	s.calculateUtilization(operatorReports, orders, callHistory, shifts, dateFrom, dateTo)
+	paidOrders := s.getPaidOrders(orders, operatorReports, dateFrom, dateTo)
+	clawbacks = s.applyClawbacks(operatorReports, clawbacks)
+	lateOrders, pending := s.settlePendingOrders(operatorReports, pending, orders)
+	for _, order := range lateOrders {
+		paidOrders = append(paidOrders, entity.PaidOrder{Id: order.Id, Operator: order.Operator, Date: order.Date, Amount: order.Amount,
+			DateFrom: dateFrom, DateTo: dateTo})
+	}
+	var summaryClawback float64
+	for _, clawback := range clawbacks {
+		summaryClawback += clawback.Amount
+	}
 	departmentPayment := s.calculateDepartmentPayment(operatorReports)
 	departmentPricePerOrder := s.calculateDepartmentPricePerOrder(totalOrdersCount, departmentPayment)
-	var telephonyPayment, smsPayment float64
-	if !debug {
-		telephonyPayment = s.getFloat64FromIO(readWriter, "Сколько заплатили за телефонию?")
-		smsPayment = s.getFloat64FromIO(readWriter, "Сколько заплатили за СМС?")
-	} else {
-		telephonyPayment = 15698.67
-		smsPayment = 5000
+	//expenses supplied by the caller are used as is, otherwise they are asked
+	expenses := inputs.Expenses
+	if expenses == nil {
+		expenses = s.askExpenses(readWriter)
 	}
 
-	totalExpenses := s.calculateTotalExpenses(departmentPayment, telephonyPayment, smsPayment)
+	totalExpenses := s.calculateTotalExpenses(departmentPayment, expenses)
 	totalPricePerOrder := s.calculateTotalPricePerOrder(totalOrdersCount, totalExpenses)
+	var expensesToRevenue float64
+	if totalRevenue > 0 {
+		expensesToRevenue = totalExpenses / totalRevenue
+	}
 	cityStatistics := s.calculateCityStatistics(orders, callHistory, dateFrom, dateTo)
+	regionStatistics := s.calculateRegionStatistics(orders, callHistory, dateFrom, dateTo)
+	dailyStatistics := s.calculateDailyStatistics(orders, callHistory, dateFrom, dateTo)
 
 	return entity.WeeklyReport{
 		OperatorReports:         operatorReports,
 		DepartmentPayment:       departmentPayment,
 		DepartmentPricePerOrder: departmentPricePerOrder,
-		TelephonyPayment:        telephonyPayment,
-		SmsPayment:              smsPayment,
+		Expenses:                expenses,
 		TotalExpenses:           totalExpenses,
 		TotalOrdersCount:        totalOrdersCount,
 		TotalPricePerOrder:      totalPricePerOrder,
+		TotalRevenue:            totalRevenue,
+		AverageOrderValue:       calculateAverageOrderValue(totalRevenue, totalOrdersCount),
+		ExpensesToRevenue:       expensesToRevenue,
 		CityStatistics:          cityStatistics,
-		SummaryDepartmentSalary: departmentPayment - departmentBonus,
+		RegionStatistics:        regionStatistics,
+		SummaryDepartmentSalary: departmentPayment - departmentBonus + summaryClawback,
 		SummaryDepartmentBonus:  departmentBonus,
+		SummaryClawback:         summaryClawback,
 		SumToPay:                departmentPayment,
+		BonusPerOrder:           bonusPerOrder,
+		PersonalBonusPerOrder:   personalBonusPerOrder,
+		DailyStatistics:         dailyStatistics,
+		Clawbacks:               clawbacks,
+		LateOrders:              lateOrders,
+		PaidOrders:              paidOrders,
+		PendingOrders:           pending,
+		MeasuredDurations:       s.talkTimeSettings.Measured,
 		DateFrom:                dateFrom,
 		DateTo:                  dateTo,
 	}
 }
 
+func (s *service) GetGradeAttainment(total entity.DatabaseStatistic, daysPassed, daysLeft int) entity.GradeAttainment {
+	uniqCalls := total.UniqIncomingCalls + total.UniqOutgoingCalls
+	attainment := entity.GradeAttainment{
+		OrdersCount: total.OrdersCount,
+		UniqCalls:   uniqCalls,
+		Conversion:  total.Conversion,
+		DaysPassed:  daysPassed,
+		DaysLeft:    daysLeft,
+	}
+
+	for _, grade := range s.getMotivationGrades() {
+		if total.Conversion*100 > grade {
+			attainment.GradeReached = true
+			attainment.CurrentGrade = grade
+			attainment.CurrentBonusPerOrder = s.motivationMap[grade]
+		} else {
+			attainment.HasNextGrade = true
+			attainment.NextGrade = grade
+			attainment.NextBonusPerOrder = s.motivationMap[grade]
+			break
+		}
+	}
+	if !attainment.HasNextGrade {
+		return attainment
+	}
+
+	attainment.OrdersToNextGrade = s.calculateOrdersToGrade(attainment.NextGrade, uniqCalls, total.OrdersCount)
+	attainment.ConversionPointsToNextGrade = attainment.NextGrade - total.Conversion*100
+
+	if daysPassed > 0 {
+		weekLength := float64(daysPassed + daysLeft)
+		attainment.ProjectedOrders = int(math.Round(float64(total.OrdersCount) / float64(daysPassed) * weekLength))
+		attainment.ProjectedCalls = int(math.Round(float64(uniqCalls) / float64(daysPassed) * weekLength))
+		attainment.ProjectedOrdersToNextGrade = s.calculateOrdersToGrade(attainment.NextGrade, attainment.ProjectedCalls, total.OrdersCount)
+		if daysLeft > 0 {
+			attainment.OrdersPerDayToNextGrade = float64(attainment.ProjectedOrdersToNextGrade) / float64(daysLeft)
+		}
+	}
+
+	return attainment
+}
+
+// calculateOrdersToGrade returns how many orders must be added so that conversion strictly exceeds the grade
+func (s *service) calculateOrdersToGrade(grade float64, uniqCalls, ordersCount int) int {
+	ordersToGrade := int(math.Floor(grade*float64(uniqCalls)/100)) + 1 - ordersCount
+	if ordersToGrade < 0 {
+		return 0
+	}
+	return ordersToGrade
+}
+
+func (s *service) GetCityStatistics(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) []entity.CityStatistic {
+	return s.calculateCityStatistics(orders, callHistory, dateFrom, dateTo)
+}
+
+// isDateBetween checks the date against the period of whole days, dateTo is included until its end
 func (s *service) isDateBetween(dateFrom, dateTo, date time.Time) bool {
-	return date == dateFrom || date.After(dateFrom) && date.Before(dateTo) || date == dateTo
+	return !date.Before(truncateToDay(dateFrom)) && date.Before(truncateToDay(dateTo).AddDate(0, 0, 1))
+}
+func (s *service) getUniqCallsWithFilter(historyRecords []entity.HistoryRecord, dateFrom time.Time, filter func(record entity.HistoryRecord) bool) map[string]int {
+	return s.countUniqCalls(historyRecords, dateFrom, filter, s.getCityByLine)
 }
-func (s *service) getUniqCallsWithFilter(historyRecords []entity.HistoryRecord, filter func(record entity.HistoryRecord) bool) map[string]int {
-	uniqCallsMap := make(map[string]struct{})
+func (s *service) countUniqCalls(historyRecords []entity.HistoryRecord, dateFrom time.Time,
+	filter func(record entity.HistoryRecord) bool, groupByLine func(lineNumber string) (string, bool)) map[string]int {
+	uniqCallsTracker := s.newUniqCallsTracker(dateFrom)
 
 	result := make(map[string]int)
 
 	for _, record := range historyRecords {
-		if _, ok := uniqCallsMap[record.Abonent]; !ok {
-			uniqCallsMap[record.Abonent] = struct{}{}
-			if filter(record) {
-				for city, r := range s.citiesAndLines {
-					if r.MatchString(record.LineNumber) {
-						result[city]++
-						break
-					}
-				}
+		if uniqCallsTracker.isUniq(record) && filter(record) {
+			if group, ok := groupByLine(record.LineNumber); ok {
+				result[group]++
 			}
 		}
 	}
 
 	return result
 }
-func (s *service) calculateBonus(databaseStatistics []entity.DatabaseStatistic, readWriter io.ReadWriter) (totalBonus, personalBonusPerOrder float64) {
+func (s *service) calculateBonus(databaseStatistics []entity.DatabaseStatistic, talkTimePerOperator map[string]entity.TalkTimeStatistic, inputs entity.ReportInputs,
+	readWriter io.ReadWriter) (totalBonus, generalBonusPerOrder, personalBonusPerOrder float64) {
 	totalDepartmentStatistics := databaseStatistics[len(databaseStatistics)-1]
-	generalBonusPerOrder := s.calculateGeneralBonusPerOrder(totalDepartmentStatistics.Conversion)
+	generalBonusPerOrder = s.calculateGeneralBonusPerOrder(totalDepartmentStatistics.Conversion)
 	if generalBonusPerOrder >= 0 {
 		totalBonus = math.RoundToEven(generalBonusPerOrder * float64(totalDepartmentStatistics.OrdersCount))
-		if !debug {
-			personalBonusPerOrder = s.setPersonalBonusPerOrder(databaseStatistics, totalDepartmentStatistics.Conversion, generalBonusPerOrder, totalBonus, readWriter)
-		} else {
+		switch {
+		case inputs.PersonalBonusPerOrder != nil:
+			personalBonusPerOrder = *inputs.PersonalBonusPerOrder
+		case inputs.BonusFromGrade:
+			personalBonusPerOrder = generalBonusPerOrder
+		case !debug:
+			personalBonusPerOrder = s.setPersonalBonusPerOrder(databaseStatistics, talkTimePerOperator, totalDepartmentStatistics.Conversion, generalBonusPerOrder, totalBonus, readWriter)
+		default:
 			personalBonusPerOrder = 19
 		}
 
 	}
-	return totalBonus, personalBonusPerOrder
+	return totalBonus, generalBonusPerOrder, personalBonusPerOrder
 }
 func (s *service) calculateGeneralBonusPerOrder(totalConversion float64) (generalBonusPerOrder float64) {
-	motivationGrades := make([]float64, 0, len(s.motivationMap))
-	for grade := range s.motivationMap {
-		motivationGrades = append(motivationGrades, grade)
-	}
-	sort.Slice(motivationGrades, func(i, j int) bool { return motivationGrades[i] > motivationGrades[j] })
-
-	for _, grade := range motivationGrades {
-		if totalConversion*100 > grade {
-			generalBonusPerOrder = s.motivationMap[grade]
+	motivationGrades := s.getMotivationGrades()
+	for i := len(motivationGrades) - 1; i >= 0; i-- {
+		if totalConversion*100 > motivationGrades[i] {
+			generalBonusPerOrder = s.motivationMap[motivationGrades[i]]
 			break
 		}
 	}
 	return generalBonusPerOrder
 }
-func (s *service) setPersonalBonusPerOrder(databaseStatistics []entity.DatabaseStatistic,
+func (s *service) getMotivationGrades() []float64 {
+	motivationGrades := make([]float64, 0, len(s.motivationMap))
+	for grade := range s.motivationMap {
+		motivationGrades = append(motivationGrades, grade)
+	}
+	sort.Float64s(motivationGrades)
+	return motivationGrades
+}
+func (s *service) setPersonalBonusPerOrder(databaseStatistics []entity.DatabaseStatistic, talkTimePerOperator map[string]entity.TalkTimeStatistic,
 	totalDepartmentConversion, generalBonusPerOrder, totalBonus float64, readWriter io.ReadWriter) (personalBonusPerOrder float64) {
 	if generalBonusPerOrder <= 0 {
 		_, _ = readWriter.Write([]byte(fmt.Sprintf("Мои соболезнования, на премию не заработали. Конверсия составила %g\n",
@@ -255,7 +386,8 @@ func (s *service) setPersonalBonusPerOrder(databaseStatistics []entity.DatabaseS
 			var answerString strings.Builder
 
 			for i := 0; i < len(databaseStatistics)-2; i++ {
-				operatorBonus := s.calculatePersonalBonus(databaseStatistics[i].Conversion, databaseStatistics[i].OrdersCount, personalBonusPerOrder)
+				operatorBonus := s.calculatePersonalBonus(databaseStatistics[i].Conversion, databaseStatistics[i].OrdersCount, personalBonusPerOrder,
+					talkTimePerOperator[databaseStatistics[i].Operator])
 				summaryOperatorsBonus += operatorBonus
 
 				answerString.WriteString(fmt.Sprintln(databaseStatistics[i].Operator, operatorBonus))
@@ -267,42 +399,65 @@ func (s *service) setPersonalBonusPerOrder(databaseStatistics []entity.DatabaseS
 	}
 	return personalBonusPerOrder
 }
-func (s *service) calculatePersonalBonus(conversion float64, ordersCount int, personalBonusPerOrder float64) (personalBonus float64) {
-	if conversion > s.minConversionGrade {
+func (s *service) calculatePersonalBonus(conversion float64, ordersCount int, personalBonusPerOrder float64,
+	talkTime entity.TalkTimeStatistic) (personalBonus float64) {
+	if conversion > s.minConversionGrade && s.talkTimeSettings.allowsBonus(talkTime) {
 		personalBonus = float64(int(math.RoundToEven(personalBonusPerOrder*float64(ordersCount))) / 100 * 100)
 	}
 	return personalBonus
 }
 func (s *service) calculateOperatorsReport(databaseStatistics []entity.DatabaseStatistic,
-	totalBonus, personalBonusPerOrder float64) []entity.OperatorReport {
+	totalBonus, personalBonusPerOrder float64, operatorsPay, holidayPay map[string]float64, totalHolidayExtraOrders float64,
+	talkTimePerOperator map[string]entity.TalkTimeStatistic, revenuePerOperator map[string]float64) []entity.OperatorReport {
 	operatorsReport := make([]entity.OperatorReport, 0, len(databaseStatistics)-2)
 	var summaryOperatorsBonus float64
 
 	for i := 0; i < len(databaseStatistics)-2; i++ {
-		currentOperatorSalary := float64(databaseStatistics[i].OrdersCount) * s.orderFee
-		currentOperatorBonus := s.calculatePersonalBonus(databaseStatistics[i].Conversion, databaseStatistics[i].OrdersCount, personalBonusPerOrder)
+		currentOperatorHolidayPay := holidayPay[databaseStatistics[i].Operator]
+		currentOperatorSalary := operatorsPay[databaseStatistics[i].Operator]
+		currentOperatorRevenue := revenuePerOperator[databaseStatistics[i].Operator]
+		currentOperatorOrderFee, currentOperatorCommissionRate := s.orderFee, 0.0
+		if len(s.commissionTiers) > 0 {
+			currentOperatorOrderFee = 0
+			if currentOperatorRevenue > 0 {
+				currentOperatorCommissionRate = (currentOperatorSalary - currentOperatorHolidayPay) / currentOperatorRevenue
+			}
+		}
+		currentOperatorTalkTime := talkTimePerOperator[databaseStatistics[i].Operator]
+		currentOperatorBonus := s.calculatePersonalBonus(databaseStatistics[i].Conversion, databaseStatistics[i].OrdersCount, personalBonusPerOrder,
+			currentOperatorTalkTime)
 		currentOperatorSummaryPay := currentOperatorSalary + currentOperatorBonus
 		currentOperatorPricePerOrder := currentOperatorSummaryPay / float64(databaseStatistics[i].OrdersCount)
 		currentOperatorUniqCalls := databaseStatistics[i].UniqIncomingCalls + databaseStatistics[i].UniqOutgoingCalls
 		summaryOperatorsBonus += currentOperatorBonus
 		operatorsReport = append(operatorsReport, entity.OperatorReport{
-			Name:           databaseStatistics[i].Operator,
-			Salary:         currentOperatorSalary,
-			Bonus:          currentOperatorBonus,
-			SummaryPayment: currentOperatorSummaryPay,
-			OrdersCount:    databaseStatistics[i].OrdersCount,
-			PricePerOrder:  currentOperatorPricePerOrder,
-			UniqCalls:      currentOperatorUniqCalls,
-			Conversion:     databaseStatistics[i].Conversion,
+			Name:              databaseStatistics[i].Operator,
+			Salary:            currentOperatorSalary,
+			HolidayPay:        currentOperatorHolidayPay,
+			Bonus:             currentOperatorBonus,
+			SummaryPayment:    currentOperatorSummaryPay,
+			OrdersCount:       databaseStatistics[i].OrdersCount,
+			PricePerOrder:     currentOperatorPricePerOrder,
+			UniqCalls:         currentOperatorUniqCalls,
+			Conversion:        databaseStatistics[i].Conversion,
+			OrderFee:          currentOperatorOrderFee,
+			ConversionGrade:   s.minConversionGrade,
+			Revenue:           currentOperatorRevenue,
+			AverageOrderValue: calculateAverageOrderValue(currentOperatorRevenue, databaseStatistics[i].OrdersCount),
+			CommissionRate:    currentOperatorCommissionRate,
+			TalkTime:          currentOperatorTalkTime,
 		})
 	}
-	bossSalary := float64(databaseStatistics[len(databaseStatistics)-1].OrdersCount) * 20
+	bossHolidayPay := totalHolidayExtraOrders * bossOrderFee
+	bossSalary := float64(databaseStatistics[len(databaseStatistics)-1].OrdersCount)*bossOrderFee + bossHolidayPay
 	bossBonus := totalBonus - summaryOperatorsBonus
 	operatorsReport = append(operatorsReport, entity.OperatorReport{
 		Name:           "Виктор",
 		Salary:         bossSalary,
+		HolidayPay:     bossHolidayPay,
 		Bonus:          bossBonus,
 		SummaryPayment: bossSalary + bossBonus,
+		OrderFee:       bossOrderFee,
 	})
 
 	return operatorsReport
@@ -331,9 +486,6 @@ func (s *service) getFloat64FromIO(readWriter io.ReadWriter, message string) flo
 	}
 	return floatFromConsole
 }
-func (s *service) calculateTotalExpenses(departmentPayment, telephonyPayment, smsPayment float64) float64 {
-	return departmentPayment + telephonyPayment + smsPayment
-}
 func (s *service) calculateTotalPricePerOrder(totalOrdersCount int, totalExpenses float64) (totalPricePerOrder float64) {
 	if totalOrdersCount > 0 {
 		totalPricePerOrder = totalExpenses / float64(totalOrdersCount)
@@ -342,19 +494,51 @@ func (s *service) calculateTotalPricePerOrder(totalOrdersCount int, totalExpense
 }
 func (s *service) calculateCityStatistics(orders []entity.Orders, callHistory []entity.HistoryRecord,
 	dateFrom, dateTo time.Time) []entity.CityStatistic {
-	citiesCount := len(s.citiesAndLines)
-	citiesNames := make([]string, 0, citiesCount)
-	for cityName := range s.citiesAndLines {
-		citiesNames = append(citiesNames, cityName)
+	return s.calculateGroupStatistics(s.cities, s.getCityByLine, s.getOrderCity, orders, callHistory, dateFrom, dateTo)
+}
+
+// calculateRegionStatistics sums the cities per region, nil when no region is configured
+func (s *service) calculateRegionStatistics(orders []entity.Orders, callHistory []entity.HistoryRecord,
+	dateFrom, dateTo time.Time) []entity.CityStatistic {
+	if len(s.regions) == 0 {
+		return nil
 	}
+	return s.calculateGroupStatistics(s.regions, s.getRegionByLine, s.getOrderRegion, orders, callHistory, dateFrom, dateTo)
+}
 
-	cityStatistics := make([]entity.CityStatistic, 0, citiesCount)
+// calculateGroupStatistics makes the statistics of the cities or regions in the given order, the last one is the total
+func (s *service) calculateGroupStatistics(citiesNames []string, groupByLine func(lineNumber string) (string, bool),
+	groupOfOrder func(order entity.Orders) (string, bool), orders []entity.Orders, callHistory []entity.HistoryRecord,
+	dateFrom, dateTo time.Time) []entity.CityStatistic {
+	cityStatistics := make([]entity.CityStatistic, 0, len(citiesNames)+1)
 
-	uniqTotalCallsCountPerCity := s.GetUniqTotalCallsCountPerCity(callHistory, dateFrom, dateTo)
-	uniqReceivedCallsCountPerCity := s.GetUniqReceivedCallsCountPerCity(callHistory, dateFrom, dateTo)
-	ordersPerCity := s.GetOrdersPerCity(orders)
+	uniqTotalCallsCountPerCity := s.countUniqCalls(callHistory, dateFrom, func(record entity.HistoryRecord) bool {
+		return s.isDateBetween(dateFrom, dateTo, record.Date)
+	}, groupByLine)
+	uniqReceivedCallsCountPerCity := s.countUniqCalls(callHistory, dateFrom, func(record entity.HistoryRecord) bool {
+		return s.isDateBetween(dateFrom, dateTo, record.Date) && record.Operator != ""
+	}, groupByLine)
+	ordersPerCity := make(map[string]int)
+	revenuePerCity := make(map[string]float64)
+	for _, order := range orders {
+		if city, ok := groupOfOrder(order); ok {
+			ordersPerCity[city]++
+			revenuePerCity[city] += order.Amount
+		}
+	}
+	linkedOrdersPerCity := make(map[string]int)
+	for _, linkedOrder := range s.linkOrdersToCalls(orders, callHistory) {
+		if !linkedOrder.Linked {
+			continue
+		}
+		if city, ok := groupByLine(linkedOrder.Call.LineNumber); ok {
+			linkedOrdersPerCity[city]++
+		}
+	}
+	_, talkTimePerCity := s.getTalkTimeStatistics(callHistory, dateFrom, dateTo, groupByLine)
 
-	var uniqCallsTotalGeneral, uniqCallsReceivedGeneral, uniqCallsMissedGeneral, ordersCountGeneral int
+	var uniqCallsTotalGeneral, uniqCallsReceivedGeneral, uniqCallsMissedGeneral, ordersCountGeneral, linkedOrdersCountGeneral int
+	var revenueGeneral float64
 	for _, city := range citiesNames {
 		uniqCallsTotal := uniqTotalCallsCountPerCity[city]
 		uniqCallsTotalGeneral += uniqCallsTotal
@@ -364,30 +548,66 @@ func (s *service) calculateCityStatistics(orders []entity.Orders, callHistory []
 		uniqCallsMissedGeneral += uniqCallsMissed
 		ordersCount := ordersPerCity[city]
 		ordersCountGeneral += ordersCount
-		conversion := s.calculateConversion(uniqCallsTotal, ordersCount)
+		linkedOrdersCount := linkedOrdersPerCity[city]
+		linkedOrdersCountGeneral += linkedOrdersCount
+		revenue := revenuePerCity[city]
+		revenueGeneral += revenue
 		cityStatistics = append(cityStatistics, entity.CityStatistic{
 			City:              city,
 			UniqCallsTotal:    uniqCallsTotal,
 			UniqCallsReceived: uniqCallsReceived,
 			UniqCallsMissed:   uniqCallsMissed,
 			OrdersCount:       ordersCount,
-			Conversion:        conversion,
+			LinkedOrdersCount: linkedOrdersCount,
+			Conversion:        s.calculateConversion(uniqCallsTotal, linkedOrdersCount),
+			Revenue:           revenue,
+			AverageOrderValue: calculateAverageOrderValue(revenue, ordersCount),
+			TalkTime:          talkTimePerCity[city],
 		})
 	}
 
-	sort.Slice(cityStatistics, func(i, j int) bool { return cityStatistics[i].UniqCallsTotal > cityStatistics[j].UniqCallsTotal })
-
 	cityStatistics = append(cityStatistics, entity.CityStatistic{
-		City:              "Итого",
+		City:              totalCity,
 		UniqCallsTotal:    uniqCallsTotalGeneral,
 		UniqCallsReceived: uniqCallsReceivedGeneral,
 		UniqCallsMissed:   uniqCallsMissedGeneral,
 		OrdersCount:       ordersCountGeneral,
-		Conversion:        s.calculateConversion(uniqCallsTotalGeneral, ordersCountGeneral),
+		LinkedOrdersCount: linkedOrdersCountGeneral,
+		Conversion:        s.calculateConversion(uniqCallsTotalGeneral, linkedOrdersCountGeneral),
+		Revenue:           revenueGeneral,
+		AverageOrderValue: calculateAverageOrderValue(revenueGeneral, ordersCountGeneral),
+		TalkTime:          talkTimePerCity[totalCity],
 	})
 
 	return cityStatistics
 }
+func (s *service) calculateDailyStatistics(orders []entity.Orders, callHistory []entity.HistoryRecord,
+	dateFrom, dateTo time.Time) []entity.DailyStatistic {
+	uniqCallsTracker := s.newUniqCallsTracker(dateFrom)
+	uniqCallsPerDay := make(map[time.Time]int)
+	for _, record := range callHistory {
+		if uniqCallsTracker.isUniq(record) && s.isDateBetween(dateFrom, dateTo, record.Date) {
+			uniqCallsPerDay[truncateToDay(record.Date)]++
+		}
+	}
+
+	ordersPerDay := make(map[time.Time]int)
+	for _, order := range orders {
+		ordersPerDay[truncateToDay(order.Date)]++
+	}
+
+	dailyStatistics := make([]entity.DailyStatistic, 0, daysInclusive(dateFrom, dateTo))
+	for day := truncateToDay(dateFrom); !day.After(dateTo); day = day.AddDate(0, 0, 1) {
+		dailyStatistics = append(dailyStatistics, entity.DailyStatistic{
+			Date:        day,
+			UniqCalls:   uniqCallsPerDay[day],
+			OrdersCount: ordersPerDay[day],
+			Conversion:  s.calculateConversion(uniqCallsPerDay[day], ordersPerDay[day]),
+			Holiday:     s.getDayLabel(day),
+		})
+	}
+	return dailyStatistics
+}
 func (s *service) calculateConversion(uniqCallsCount, orders int) (conversion float64) {
 	if uniqCallsCount > 0 {
 		conversion = float64(orders) / float64(uniqCallsCount)
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestIsDateBetween(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2024, time.March, 25, 0, 0, 0, 0, berlin)
+	sunday := time.Date(2024, time.March, 31, 0, 0, 0, 0, berlin)
+	s := &service{}
+
+	tests := []struct {
+		name             string
+		dateFrom, dateTo time.Time
+		date             time.Time
+		want             bool
+	}{
+		{"start of the first day", monday, sunday, monday, true},
+		{"last second before the period", monday, sunday, time.Date(2024, time.March, 24, 23, 59, 59, 0, berlin), false},
+		{"late on the last day", monday, sunday, time.Date(2024, time.March, 31, 23, 59, 59, 0, berlin), true},
+		{"midnight after the last day", monday, sunday, time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin), false},
+		{"last day given with a time", monday.Add(9 * time.Hour), sunday.Add(12 * time.Hour), time.Date(2024, time.March, 31, 22, 15, 0, 0, berlin), true},
+		{"first day given with a time", monday.Add(9 * time.Hour), sunday.Add(12 * time.Hour), monday.Add(5 * time.Minute), true},
+		{"single day on the autumn DST change", time.Date(2024, time.October, 27, 0, 0, 0, 0, berlin), time.Date(2024, time.October, 27, 0, 0, 0, 0, berlin),
+			time.Date(2024, time.October, 27, 23, 59, 59, 0, berlin), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := s.isDateBetween(test.dateFrom, test.dateTo, test.date); got != test.want {
+				t.Errorf("isDateBetween(%s, %s, %s) = %v, want %v", test.dateFrom, test.dateTo, test.date, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"slices"
+	"time"
+)
+
+// calculateUtilization adds the schedule based metrics to the operators' reports, nothing is added without a schedule for the period
+func (s *service) calculateUtilization(operatorReports []entity.OperatorReport, orders []entity.Orders, callHistory []entity.HistoryRecord,
+	shifts []entity.Shift, dateFrom, dateTo time.Time) {
+	if len(shifts) == 0 {
+		return
+	}
+
+	shiftsByOperator := make(map[string][]entity.Shift)
+	hoursWorked := make(map[string]float64)
+	for _, shift := range shifts {
+		shiftsByOperator[shift.Operator] = append(shiftsByOperator[shift.Operator], shift)
+		hoursWorked[shift.Operator] += shift.Hours()
+	}
+
+	uniqCallsInShift := make(map[string]int)
+	offShiftCalls := make(map[string]int)
+	uniqCallsTracker := s.newUniqCallsTracker(dateFrom)
+	for _, record := range callHistory {
+		isUniq := uniqCallsTracker.isUniq(record)
+		if !s.isDateBetween(dateFrom, dateTo, record.Date) || !slices.Contains(s.operators, record.Operator) {
+			continue
+		}
+		switch {
+		case !isInShift(shiftsByOperator[record.Operator], record.Date):
+			offShiftCalls[record.Operator]++
+		case isUniq:
+			uniqCallsInShift[record.Operator]++
+		}
+	}
+
+	ordersInShift := make(map[string]int)
+	for _, order := range orders {
+		if isInShift(shiftsByOperator[order.Operator], order.Date) {
+			ordersInShift[order.Operator]++
+		}
+	}
+
+	for i := range operatorReports {
+		name := operatorReports[i].Name
+		if !slices.Contains(s.operators, name) {
+			continue
+		}
+		if hours := hoursWorked[name]; hours > 0 {
+			operatorReports[i].HoursWorked = hours
+			operatorReports[i].OrdersPerHour = float64(operatorReports[i].OrdersCount) / hours
+			operatorReports[i].CallsPerHour = float64(operatorReports[i].UniqCalls) / hours
+		}
+		operatorReports[i].ShiftConversion = s.calculateConversion(uniqCallsInShift[name], ordersInShift[name])
+		operatorReports[i].OffShiftCalls = offShiftCalls[name]
+	}
+}
+
+// detectOffShiftCalls reports the operators who took calls on the day outside their shifts, the days without a schedule are skipped
+func (s *service) detectOffShiftCalls(callHistory []entity.HistoryRecord, shifts []entity.Shift, day time.Time) []entity.Anomaly {
+	anomalies := make([]entity.Anomaly, 0)
+	shiftsByOperator := make(map[string][]entity.Shift)
+	var scheduled bool
+	for _, shift := range shifts {
+		shiftsByOperator[shift.Operator] = append(shiftsByOperator[shift.Operator], shift)
+		scheduled = scheduled || truncateToDay(shift.From).Equal(day)
+	}
+	if !scheduled {
+		return anomalies
+	}
+
+	offShiftCalls := newDailyCounter()
+	for _, record := range callHistory {
+		if truncateToDay(record.Date).Equal(day) && slices.Contains(s.operators, record.Operator) &&
+			!isInShift(shiftsByOperator[record.Operator], record.Date) {
+			offShiftCalls.add(record.Operator, day)
+		}
+	}
+	for _, operator := range offShiftCalls.names() {
+		anomalies = append(anomalies, entity.Anomaly{
+			Kind:    entity.AnomalyCallsOffShift,
+			Subject: "Оператор " + operator,
+			Date:    day,
+			Value:   offShiftCalls[operator][day],
+		})
+	}
+	return anomalies
+}
+
+func isInShift(shifts []entity.Shift, date time.Time) bool {
+	for _, shift := range shifts {
+		if shift.Contains(date) {
+			return true
+		}
+	}
+	return false
+}
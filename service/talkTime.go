@@ -0,0 +1,105 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"time"
+)
+
+// TalkTimeSettings set the short call threshold and the optional talk time conditions of the personal bonus,
+// the zero values turn the conditions off. Measured are the durations Mango data has, the conditions need the talk time
+type TalkTimeSettings struct {
+	ShortCall                 time.Duration
+	BonusMaxShortCallsShare   float64
+	BonusMinAverageHandleTime time.Duration
+	Measured                  entity.MeasuredDurations
+}
+
+func (t TalkTimeSettings) Validate() error {
+	if t.ShortCall < 0 || t.BonusMinAverageHandleTime < 0 {
+		return fmt.Errorf("длительности не могут быть отрицательными")
+	}
+	if t.BonusMaxShortCallsShare < 0 || t.BonusMaxShortCallsShare > 1 {
+		return fmt.Errorf("доля коротких звонков задается от 0 до 1")
+	}
+	return nil
+}
+
+// allowsBonus checks the talk time conditions, operators without answered calls in the data are not held back
+func (t TalkTimeSettings) allowsBonus(statistic entity.TalkTimeStatistic) bool {
+	if statistic.AnsweredCalls == 0 {
+		return true
+	}
+	if t.BonusMaxShortCallsShare > 0 && statistic.ShortCallsShare > t.BonusMaxShortCallsShare {
+		return false
+	}
+	return statistic.AverageHandleTime >= t.BonusMinAverageHandleTime
+}
+
+type talkTimeCounter struct {
+	calls, answered, short       int
+	talkTime, waitTime, ringTime time.Duration
+}
+
+func (c *talkTimeCounter) add(record entity.HistoryRecord, shortCall time.Duration) {
+	c.calls++
+	c.waitTime += record.WaitTime
+	c.ringTime += record.RingTime
+	if record.Operator == "" {
+		return
+	}
+	c.answered++
+	c.talkTime += record.TalkTime
+	if record.TalkTime < shortCall {
+		c.short++
+	}
+}
+
+func (c *talkTimeCounter) statistic() entity.TalkTimeStatistic {
+	statistic := entity.TalkTimeStatistic{AnsweredCalls: c.answered, TotalTalkTime: c.talkTime}
+	if c.calls > 0 {
+		statistic.AverageWaitTime = c.waitTime / time.Duration(c.calls)
+		statistic.AverageRingTime = c.ringTime / time.Duration(c.calls)
+	}
+	if c.answered > 0 {
+		statistic.AverageHandleTime = c.talkTime / time.Duration(c.answered)
+		statistic.ShortCallsShare = float64(c.short) / float64(c.answered)
+	}
+	return statistic
+}
+
+// getTalkTimeStatistics aggregates the durations of the period's calls per operator and per city or region the line belongs to,
+// the "Итого" city holds all the cities' calls
+func (s *service) getTalkTimeStatistics(callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time,
+	groupByLine func(lineNumber string) (string, bool)) (perOperator, perCity map[string]entity.TalkTimeStatistic) {
+	operatorCounters := make(map[string]*talkTimeCounter)
+	cityCounters := map[string]*talkTimeCounter{totalCity: {}}
+	for _, record := range callHistory {
+		if !s.isDateBetween(dateFrom, dateTo, record.Date) {
+			continue
+		}
+		if record.Operator != "" {
+			if _, ok := operatorCounters[record.Operator]; !ok {
+				operatorCounters[record.Operator] = &talkTimeCounter{}
+			}
+			operatorCounters[record.Operator].add(record, s.talkTimeSettings.ShortCall)
+		}
+		if city, ok := groupByLine(record.LineNumber); ok {
+			if _, ok := cityCounters[city]; !ok {
+				cityCounters[city] = &talkTimeCounter{}
+			}
+			cityCounters[city].add(record, s.talkTimeSettings.ShortCall)
+			cityCounters[totalCity].add(record, s.talkTimeSettings.ShortCall)
+		}
+	}
+
+	perOperator = make(map[string]entity.TalkTimeStatistic, len(operatorCounters))
+	for operator, counter := range operatorCounters {
+		perOperator[operator] = counter.statistic()
+	}
+	perCity = make(map[string]entity.TalkTimeStatistic, len(cityCounters))
+	for city, counter := range cityCounters {
+		perCity[city] = counter.statistic()
+	}
+	return perOperator, perCity
+}
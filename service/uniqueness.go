@@ -0,0 +1,73 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"time"
+)
+
+const (
+	FirstEver     = "firstEver"
+	FirstInPeriod = "firstInPeriod"
+	FirstInDays   = "firstInDays"
+)
+
+// UniquenessPolicy decides when a call from an already known abonent is counted as unique again
+type UniquenessPolicy struct {
+	Mode string
+	Days int
+}
+
+func (p UniquenessPolicy) Validate() error {
+	switch p.Mode {
+	case FirstEver, FirstInPeriod:
+		return nil
+	case FirstInDays:
+		if p.Days <= 0 {
+			return fmt.Errorf("uniqueness.days must be positive for policy %s, got %d", FirstInDays, p.Days)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown uniqueness.policy %q, expected one of %s, %s, %s", p.Mode, FirstEver, FirstInPeriod, FirstInDays)
+	}
+}
+
+type uniqCallsTracker struct {
+	policy    UniquenessPolicy
+	dateFrom  time.Time
+	lastCalls map[string]time.Time
+}
+
+func (s *service) newUniqCallsTracker(dateFrom time.Time) *uniqCallsTracker {
+	return &uniqCallsTracker{
+		policy:    s.uniquenessPolicy,
+		dateFrom:  dateFrom,
+		lastCalls: make(map[string]time.Time),
+	}
+}
+
+// isUniq expects records in chronological order, the calls without a number are never unique
+func (t *uniqCallsTracker) isUniq(record entity.HistoryRecord) bool {
+	abonent := normalizePhone(record.Abonent)
+	if abonent == "" {
+		return false
+	}
+	lastCall, seen := t.lastCalls[abonent]
+
+	switch t.policy.Mode {
+	case FirstInPeriod:
+		if record.Date.Before(t.dateFrom) {
+			return false
+		}
+		t.lastCalls[abonent] = record.Date
+		return !seen
+	case FirstInDays:
+		t.lastCalls[abonent] = record.Date
+		return !seen || !record.Date.Before(lastCall.AddDate(0, 0, t.policy.Days))
+	default:
+		if !seen {
+			t.lastCalls[abonent] = record.Date
+		}
+		return !seen
+	}
+}
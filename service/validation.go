@@ -0,0 +1,96 @@
+package service
+
+import (
+	"callCenterReportMaker/entity"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateData checks the orders and calls fetched for the report, the issues are grouped by kind
+func (s *service) ValidateData(orders []entity.Orders, callHistory []entity.HistoryRecord, dateFrom, dateTo time.Time) []entity.DataIssue {
+	var invalidOrderDates, invalidCallDates, ordersOutOfPeriod int
+	unknownOperators := make(map[string]int)
+	unknownCities := make(map[string]int)
+	ambiguousCities := make(map[string]int)
+	orderIds := make(map[string]int, len(orders))
+	for _, order := range orders {
+		orderIds[strconv.FormatUint(uint64(order.Id), 10)]++
+		switch {
+		case order.Date.IsZero():
+			invalidOrderDates++
+		case !s.isDateBetween(dateFrom, dateTo, order.Date):
+			ordersOutOfPeriod++
+		}
+		if order.Operator != "" && !slices.Contains(s.operators, order.Operator) {
+			unknownOperators[order.Operator]++
+		}
+		switch candidates := s.getOrderCityCandidates(order); {
+		case len(candidates) > 1:
+			ambiguousCities[fmt.Sprintf("%s (%s)", order.City, strings.Join(candidates, ", "))]++
+		case len(candidates) == 0:
+			if _, ok := s.getOrderCity(order); !ok {
+				unknownCities[order.City]++
+			}
+		}
+	}
+
+	//the history is ordered by date, an unreadable date lies between the readable ones around it
+	nextDates := make([]time.Time, len(callHistory))
+	var next time.Time
+	for i := len(callHistory) - 1; i >= 0; i-- {
+		nextDates[i] = next
+		if !callHistory[i].Date.IsZero() {
+			next = callHistory[i].Date
+		}
+	}
+	var previous time.Time
+	for i, record := range callHistory {
+		if !record.Date.IsZero() {
+			previous = record.Date
+			continue
+		}
+		if (previous.IsZero() || previous.Before(truncateToDay(dateTo).AddDate(0, 0, 1))) &&
+			(nextDates[i].IsZero() || !nextDates[i].Before(truncateToDay(dateFrom))) {
+			invalidCallDates++
+		}
+	}
+	lineMatchReport := s.GetLineMatchReport(callHistory, dateFrom, dateTo)
+
+	issues := make([]entity.DataIssue, 0)
+	if invalidOrderDates > 0 {
+		issues = append(issues, entity.DataIssue{Kind: entity.DataInvalidDate, Subject: "Заказы", Count: invalidOrderDates})
+	}
+	if invalidCallDates > 0 {
+		issues = append(issues, entity.DataIssue{Kind: entity.DataInvalidDate, Subject: "Звонки", Count: invalidCallDates})
+	}
+	if ordersOutOfPeriod > 0 {
+		issues = append(issues, entity.DataIssue{Kind: entity.DataOrderOutOfPeriod, Count: ordersOutOfPeriod})
+	}
+	issues = append(issues, countedIssues(entity.DataUnknownOperator, unknownOperators, 1)...)
+	issues = append(issues, countedIssues(entity.DataUnknownCity, unknownCities, 1)...)
+	issues = append(issues, countedIssues(entity.DataAmbiguousCity, ambiguousCities, 1)...)
+	issues = append(issues, countedIssues(entity.DataDuplicateOrder, orderIds, 2)...)
+	for _, line := range lineMatchReport.Unmatched {
+		issues = append(issues, entity.DataIssue{Kind: entity.DataUnmatchedLine, Subject: line.LineNumber, Count: line.Calls})
+	}
+	for _, line := range lineMatchReport.Ambiguous {
+		issues = append(issues, entity.DataIssue{Kind: entity.DataAmbiguousLine,
+			Subject: fmt.Sprintf("%s (%s)", line.LineNumber, strings.Join(line.Matches, ", ")), Count: line.Calls})
+	}
+	return issues
+}
+
+func countedIssues(kind string, counts map[string]int, minCount int) []entity.DataIssue {
+	issues := make([]entity.DataIssue, 0)
+	for subject, count := range counts {
+		if count >= minCount {
+			issues = append(issues, entity.DataIssue{Kind: kind, Subject: subject, Count: count})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Subject < issues[j].Subject })
+	return issues
+}
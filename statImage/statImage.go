@@ -0,0 +1,180 @@
+package statImage
+
+import (
+	"bytes"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"sync"
+)
+
+const (
+	fontSize     = 18
+	padding      = 12
+	rowHeight    = 32
+	barMaxLength = 480
+)
+
+var (
+	background  = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	headerColor = color.RGBA{R: 0x92, G: 0xD0, B: 0x50, A: 0xFF}
+	stripeColor = color.RGBA{R: 0xF2, G: 0xF2, B: 0xF2, A: 0xFF}
+	barColor    = color.RGBA{R: 0x44, G: 0x72, B: 0xC4, A: 0xFF}
+	textColor   = color.RGBA{A: 0xFF}
+)
+
+type Bar struct {
+	Label string
+	Value float64
+	Text  string
+}
+
+// Painter renders PNG images, the font is loaded on first use so a missing font only disables images
+type Painter interface {
+	Table(title string, header []string, rows [][]string) ([]byte, error)
+	BarChart(title string, bars []Bar) ([]byte, error)
+}
+
+type painter struct {
+	fontPath string
+	once     sync.Once
+	face     font.Face
+	err      error
+}
+
+func New(fontPath string) Painter {
+	return &painter{fontPath: fontPath}
+}
+
+func (p *painter) Table(title string, header []string, rows [][]string) ([]byte, error) {
+	face, err := p.getFace()
+	if err != nil {
+		return nil, err
+	}
+
+	colWidths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for col, text := range row {
+			if col < len(colWidths) {
+				colWidths[col] = max(colWidths[col], font.MeasureString(face, text).Ceil()+2*padding)
+			}
+		}
+	}
+	var width int
+	for _, colWidth := range colWidths {
+		width += colWidth
+	}
+	width = max(width, font.MeasureString(face, title).Ceil()) + 2*padding
+	height := (len(rows)+2)*rowHeight + 2*padding
+
+	img := p.newImage(width, height)
+	y := padding
+	p.drawText(img, face, title, padding, y)
+	y += rowHeight
+
+	for i, row := range append([][]string{header}, rows...) {
+		switch {
+		case i == 0:
+			fill(img, padding, y, width-padding, y+rowHeight, headerColor)
+		case i%2 == 0:
+			fill(img, padding, y, width-padding, y+rowHeight, stripeColor)
+		}
+		x := padding
+		for col, text := range row {
+			if col < len(colWidths) {
+				p.drawText(img, face, text, x+padding, y)
+				x += colWidths[col]
+			}
+		}
+		y += rowHeight
+	}
+
+	return encode(img)
+}
+
+func (p *painter) BarChart(title string, bars []Bar) ([]byte, error) {
+	face, err := p.getFace()
+	if err != nil {
+		return nil, err
+	}
+
+	var labelWidth, textWidth int
+	var maxValue float64
+	for _, bar := range bars {
+		labelWidth = max(labelWidth, font.MeasureString(face, bar.Label).Ceil())
+		textWidth = max(textWidth, font.MeasureString(face, bar.Text).Ceil())
+		maxValue = max(maxValue, bar.Value)
+	}
+	width := max(labelWidth+barMaxLength+textWidth+4*padding, font.MeasureString(face, title).Ceil()+2*padding)
+	height := (len(bars)+1)*rowHeight + 2*padding
+
+	img := p.newImage(width, height)
+	y := padding
+	p.drawText(img, face, title, padding, y)
+	y += rowHeight
+
+	barX := labelWidth + 2*padding
+	for _, bar := range bars {
+		p.drawText(img, face, bar.Label, padding, y)
+		var length int
+		if maxValue > 0 {
+			length = int(bar.Value / maxValue * barMaxLength)
+		}
+		fill(img, barX, y+4, barX+length, y+rowHeight-4, barColor)
+		p.drawText(img, face, bar.Text, barX+length+padding, y)
+		y += rowHeight
+	}
+
+	return encode(img)
+}
+
+func (p *painter) getFace() (font.Face, error) {
+	p.once.Do(func() {
+		var data []byte
+		data, p.err = os.ReadFile(p.fontPath)
+		if p.err != nil {
+			return
+		}
+		var parsed *opentype.Font
+		parsed, p.err = opentype.Parse(data)
+		if p.err != nil {
+			return
+		}
+		p.face, p.err = opentype.NewFace(parsed, &opentype.FaceOptions{Size: fontSize, DPI: 72, Hinting: font.HintingFull})
+	})
+	return p.face, p.err
+}
+
+func (p *painter) newImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return img
+}
+
+// drawText writes text vertically centered in the row starting at y
+func (p *painter) drawText(img *image.RGBA, face font.Face, text string, x, y int) {
+	metrics := face.Metrics()
+	baseline := y + (rowHeight+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(textColor),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	drawer.DrawString(text)
+}
+
+func fill(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func encode(img image.Image) ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+const (
+	statisticsFile = "statistics.tmpl"
+	layoutFile     = "layout.yaml"
+	dateLayout     = "02.01.2006"
+)
+
+//go:embed default
+var defaults embed.FS
+
+type XlsxLayout struct {
+	HeaderColor     string       `yaml:"headerColor"`
+	OperatorColumns []XlsxColumn `yaml:"operatorColumns"`
+	ExpenseRows     []XlsxRow    `yaml:"expenseRows"`
+	CityRows        []XlsxRow    `yaml:"cityRows"`
+	SummaryRows     []XlsxRow    `yaml:"summaryRows"`
+	DailyColumns    []XlsxColumn `yaml:"dailyColumns"`
+}
+
+type XlsxColumn struct {
+	Key    string  `yaml:"key"`
+	Header string  `yaml:"header"`
+	Width  float64 `yaml:"width"`
+	Hidden bool    `yaml:"hidden"`
+}
+
+type XlsxRow struct {
+	Key    string `yaml:"key"`
+	Label  string `yaml:"label"`
+	Color  string `yaml:"color"`
+	Bold   bool   `yaml:"bold"`
+	Hidden bool   `yaml:"hidden"`
+}
+
+// Templates reads the files on every call so the supervisor's edits apply without a restart,
+// files missing from the directory fall back to the built-in defaults
+type Templates interface {
+	Statistics() (*template.Template, error)
+	XlsxLayout() (XlsxLayout, error)
+}
+
+type templates struct {
+	dir string
+}
+
+func New(dir string) Templates {
+	return templates{dir: dir}
+}
+
+func (t templates) Statistics() (*template.Template, error) {
+	data, err := t.read(statisticsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.New(statisticsFile).Funcs(template.FuncMap{
+		"date": func(date time.Time) string { return date.Format(dateLayout) },
+	}).Parse(string(data))
+}
+
+// XlsxLayout starts from the built-in layout, so the sections missing from the edited file keep their defaults
+func (t templates) XlsxLayout() (XlsxLayout, error) {
+	var layout XlsxLayout
+	data, err := defaults.ReadFile("default/" + layoutFile)
+	if err != nil {
+		return XlsxLayout{}, err
+	}
+	if err = yaml.Unmarshal(data, &layout); err != nil {
+		return XlsxLayout{}, err
+	}
+
+	data, err = t.read(layoutFile)
+	if err != nil {
+		return XlsxLayout{}, err
+	}
+	if err = yaml.Unmarshal(data, &layout); err != nil {
+		return XlsxLayout{}, fmt.Errorf("%s: %w", layoutFile, err)
+	}
+	return layout, nil
+}
+
+func (t templates) read(name string) ([]byte, error) {
+	if t.dir != "" {
+		data, err := os.ReadFile(filepath.Join(t.dir, name))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return defaults.ReadFile("default/" + name)
+}
+
+func FindColumn(columns []XlsxColumn, key string) (XlsxColumn, bool) {
+	for _, column := range columns {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return XlsxColumn{}, false
+}
+
+func FindRow(rows []XlsxRow, key string) (XlsxRow, bool) {
+	for _, row := range rows {
+		if row.Key == key {
+			return row, true
+		}
+	}
+	return XlsxRow{}, false
+}
@@ -0,0 +1,242 @@
+package tgBot
+
+import (
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/renderer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Syfaro/telegram-bot-api"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	draftPath            = "data/draft.json"
+	draftExpensesButton  = "draft:expenses"
+	draftBonusButton     = "draft:bonus"
+	draftApproveButton   = "draft:approve"
+	draftDateLayout      = "02.01.2006"
+	draftExpensesExample = "Манго=15000; Оплата СМС сервиса=5000; Гарнитуры=3000"
+)
+
+// draft is the payroll report waiting for the admin's approval, kept on disk to survive restarts
+type draft struct {
+	DateFrom, DateTo time.Time
+	Inputs           entity.ReportInputs
+	Report           entity.WeeklyReport
+}
+
+func loadDraft() *draft {
+	data, err := os.ReadFile(draftPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Println(err)
+		}
+		return nil
+	}
+
+	var d draft
+	if err = json.Unmarshal(data, &d); err != nil {
+		log.Println(err)
+		return nil
+	}
+	return &d
+}
+
+func (s *session) getDraft() *draft {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draft
+}
+
+func (s *session) setDraft(d *draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draft = d
+
+	if d == nil {
+		if err := os.Remove(draftPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(draftPath, data, 0644)
+}
+
+// makePayrollDraft computes the previous pay period report with the default inputs and offers it to the admin
+func (t tgBot) makePayrollDraft(chats []int64) error {
+	payPeriod := t.calendar.Payroll.Previous(t.today())
+	dateFrom, dateTo := payPeriod.From, payPeriod.To
+	inputs := entity.ReportInputs{
+		Expenses:       t.controller.GetDefaultExpenses(),
+		BonusFromGrade: true,
+		//nobody waits for the question, the issues are listed in the draft and approving it accepts them
+		AcceptDataIssues: true,
+	}
+
+	report, err := t.controller.MakeReport(dateFrom, dateTo, inputs, t)
+	if err != nil {
+		return err
+	}
+	d := &draft{DateFrom: dateFrom, DateTo: dateTo, Inputs: inputs, Report: report}
+	if err = t.session.setDraft(d); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, chatId := range withDefaultChat(chats, adminChatId) {
+		errs = append(errs, t.sendDraft(chatId, d))
+	}
+	return errors.Join(errs...)
+}
+
+func (t tgBot) sendDraft(chatId int64, d *draft) error {
+	msg := tgbotapi.NewMessage(chatId, d.summary())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Расходы", draftExpensesButton),
+			tgbotapi.NewInlineKeyboardButtonData("Премия", draftBonusButton),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Утвердить", draftApproveButton),
+		),
+	)
+	_, err := t.tgApi.Send(msg)
+	return err
+}
+
+func (t tgBot) handleCallback(query *tgbotapi.CallbackQuery) {
+	if _, err := t.tgApi.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Println(err)
+	}
+	if !isAuthorized(int64(query.From.ID)) {
+		t.sendMsg(fmt.Sprintf("Неизвестный пользователь %s нажал кнопку %s", query.From, query.Data))
+		return
+	}
+
+	d := t.session.getDraft()
+	if d == nil {
+		t.sendMsg("Черновика отчета нет, он уже утвержден или еще не сформирован")
+		return
+	}
+
+	switch query.Data {
+	case draftExpensesButton:
+		t.adjustDraftExpenses(d)
+	case draftBonusButton:
+		t.adjustDraftBonus(d)
+	case draftApproveButton:
+		t.approveDraft(d)
+	default:
+		t.sendMsg("Неизвестная кнопка")
+	}
+}
+
+func (t tgBot) adjustDraftExpenses(d *draft) {
+	t.sendMsg(fmt.Sprintf("Отправьте все расходы за период одной строкой, например: %s", draftExpensesExample))
+
+	expenses, err := entity.ParseExpenses(t.readAnswer())
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	d.Inputs.Expenses = expenses
+	t.recalculateDraft(d)
+}
+
+func (t tgBot) adjustDraftBonus(d *draft) {
+	t.sendMsg(fmt.Sprintf("Сколько премии на заказ раздать операторам? По грейду отдела %g руб.", d.Report.BonusPerOrder))
+
+	bonus, err := strconv.ParseFloat(strings.ReplaceAll(t.readAnswer(), ",", "."), 64)
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	d.Inputs.PersonalBonusPerOrder = &bonus
+	d.Inputs.BonusFromGrade = false
+	t.recalculateDraft(d)
+}
+
+func (t tgBot) recalculateDraft(d *draft) {
+	report, err := t.controller.MakeReport(d.DateFrom, d.DateTo, d.Inputs, t)
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	d.Report = report
+	if err = t.session.setDraft(d); err != nil {
+		t.sendMsg(err.Error())
+	}
+	if err = t.sendDraft(adminChatId, d); err != nil {
+		t.sendMsg(err.Error())
+	}
+}
+
+// approveDraft sends the report files and the operators' payslips the same way "Отчет" and "Утвердить" do
+func (t tgBot) approveDraft(d *draft) {
+	files, err := renderer.RenderAll(d.Report, reportPath, t.reportFormats, t.templates)
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	t.session.setReportFiles(files)
+	t.sendFiles(files)
+
+	if err = t.session.setDraft(nil); err != nil {
+		t.sendMsg(err.Error())
+	}
+	report := d.Report
+	t.session.report = &report
+	t.approveReport()
+}
+
+func (t tgBot) readAnswer() string {
+	buf := make([]byte, 1024)
+	n, _ := t.Read(buf)
+	return strings.TrimSpace(string(buf[:n]))
+}
+
+func (d *draft) summary() string {
+	r := d.Report
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("Черновик отчета за %s - %s\n\n",
+		d.DateFrom.Format(draftDateLayout), d.DateTo.Format(draftDateLayout)))
+
+	for _, operatorReport := range r.OperatorReports {
+		strBuilder.WriteString(fmt.Sprintf("%s: %d заказов, ЗП %g, премия %g, итого %g руб.\n",
+			operatorReport.Name, operatorReport.OrdersCount, operatorReport.Salary, operatorReport.Bonus, operatorReport.SummaryPayment))
+	}
+
+	for _, clawback := range r.Clawbacks {
+		strBuilder.WriteString(fmt.Sprintf("Удержание %s: %s, %g руб.\n", clawback.Operator, clawback, clawback.Amount))
+	}
+	for _, order := range r.LateOrders {
+		strBuilder.WriteString(fmt.Sprintf("Оплата %s: %s, %g руб.\n", order.Operator, order, order.Amount))
+	}
+	if len(r.PendingOrders) > 0 {
+		strBuilder.WriteString(fmt.Sprintf("Ожидают подтверждения: %d заказов\n", len(r.PendingOrders)))
+	}
+	if len(r.DataIssues) > 0 {
+		strBuilder.WriteString("\nПроблемы в данных:\n")
+		for _, issue := range r.DataIssues {
+			strBuilder.WriteString(issue.String() + "\n")
+		}
+	}
+	strBuilder.WriteString(fmt.Sprintf("\nПремия на заказ: по грейду %g руб., операторам %g руб.\n", r.BonusPerOrder, r.PersonalBonusPerOrder))
+	strBuilder.WriteString(fmt.Sprintf("Отдел: %.2f руб.\n", r.DepartmentPayment))
+	for _, expense := range r.Expenses {
+		strBuilder.WriteString(fmt.Sprintf("%s: %.2f руб.\n", expense.Name, expense.Amount))
+	}
+	strBuilder.WriteString(fmt.Sprintf("Итого расходов %.2f руб., %d заказов, %.2f руб. за заказ\n",
+		r.TotalExpenses, r.TotalOrdersCount, r.TotalPricePerOrder))
+	return strBuilder.String()
+}
@@ -0,0 +1,119 @@
+package tgBot
+
+import (
+	"callCenterReportMaker/period"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sendStatisticsJob sends the current week statistics, to the working chat when the job has no chats
+func (t tgBot) sendStatisticsJob(chats []int64) error {
+	var errs []error
+	for _, chatId := range withDefaultChat(chats, t.chatId) {
+		errs = append(errs, t.sendWeeklyConversionStatistics(chatId))
+	}
+	return errors.Join(errs...)
+}
+
+// sendAnomaliesJob warns about yesterday's anomalies, to the admin when the job has no chats
+func (t tgBot) sendAnomaliesJob(chats []int64) error {
+	warning, err := t.controller.CheckAnomalies()
+	if err != nil || warning == "" {
+		return err
+	}
+	return t.sendToChats(withDefaultChat(chats, adminChatId), warning)
+}
+
+// today returns the midnight of the current day in the business location
+func (t tgBot) today() time.Time {
+	year, month, day := time.Now().In(t.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.location)
+}
+
+func (t tgBot) sendPreviousMonthJob(chats []int64) error {
+	previousMonth := period.Definition{Type: period.Month}.Previous(t.today())
+
+	return t.sendToChats(withDefaultChat(chats, adminChatId), t.controller.MakePeriodStatistics(previousMonth.From, previousMonth.To))
+}
+
+func (t tgBot) sendToChats(chats []int64, message string) error {
+	var errs []error
+	for _, chatId := range chats {
+		errs = append(errs, t.sendPreformattedMessageTo(chatId, message))
+	}
+	return errors.Join(errs...)
+}
+
+func withDefaultChat(chats []int64, defaultChat int64) []int64 {
+	if len(chats) == 0 {
+		return []int64{defaultChat}
+	}
+	return chats
+}
+
+// getPeriod takes the period named at the beginning of the command arguments, e.g. "прошлая неделя",
+// and asks the dates when there is none; the rest of the arguments is returned
+func (t tgBot) getPeriod(args []string) (dateFrom, dateTo time.Time, rest []string, err error) {
+	for words := len(args); words > 0; words-- {
+		if p, ok := t.calendar.Resolve(strings.Join(args[:words], " "), t.today()); ok {
+			return p.From, p.To, args[words:], nil
+		}
+	}
+
+	dateFrom, dateTo, err = t.askPeriod()
+	return dateFrom, dateTo, args, err
+}
+
+func (t tgBot) sendPeriodStatistics(args []string) {
+	p, ok := t.calendar.Resolve(strings.Join(args, " "), t.today())
+	if !ok {
+		t.sendMsg(fmt.Sprintf("Неизвестный период %q, например: эта неделя, прошлая неделя, этот месяц, прошлый месяц, прошлый период", strings.Join(args, " ")))
+		return
+	}
+	if err := t.sendPreformattedMessageTo(adminChatId, t.controller.MakePeriodStatistics(p.From, p.To)); err != nil {
+		t.sendMsg(err.Error())
+	}
+}
+
+// manageJobs handles "Задачи", "Задачи пауза <имя>", "Задачи продолжить <имя>" and "Задачи запуск <имя>"
+func (t tgBot) manageJobs(args []string) {
+	if len(args) == 1 {
+		statuses := t.scheduler.List()
+		if len(statuses) == 0 {
+			t.sendMsg("Задачи не настроены")
+			return
+		}
+		lines := make([]string, 0, len(statuses))
+		for _, status := range statuses {
+			lines = append(lines, status.String())
+		}
+		t.sendMsg(strings.Join(lines, "\n\n"))
+		return
+	}
+	if len(args) != 3 {
+		t.sendMsg("Использование: \"Задачи\", \"Задачи пауза <имя>\", \"Задачи продолжить <имя>\" или \"Задачи запуск <имя>\"")
+		return
+	}
+
+	var action func(name string) error
+	var done string
+	switch args[1] {
+	case "пауза":
+		action, done = t.scheduler.Pause, "поставлена на паузу"
+	case "продолжить":
+		action, done = t.scheduler.Resume, "снова активна"
+	case "запуск":
+		action, done = t.scheduler.Trigger, "запущена"
+	default:
+		t.sendMsg(fmt.Sprintf("Неизвестное действие %q", args[1]))
+		return
+	}
+
+	if err := action(args[2]); err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	t.sendMsg(fmt.Sprintf("Задача %s %s", args[2], done))
+}
@@ -0,0 +1,61 @@
+package tgBot
+
+import (
+	"fmt"
+	"github.com/Syfaro/telegram-bot-api"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const shiftsUploadPath = "data/shifts_upload%s"
+
+// importShifts takes a CSV or XLSX shift schedule sent to the bot as a document
+func (t tgBot) importShifts(document *tgbotapi.Document) {
+	path := fmt.Sprintf(shiftsUploadPath, filepath.Ext(document.FileName))
+	if err := t.downloadFile(document.FileID, path); err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+	defer func(path string) {
+		_ = os.Remove(path)
+	}(path)
+
+	reply, err := t.controller.ImportShifts(path)
+	if err != nil {
+		t.sendMsg(fmt.Sprintf("График смен не загружен: %s\nНужны столбцы Дата (ДД.ММ.ГГГГ), Оператор, Начало (ЧЧ:ММ), Конец (ЧЧ:ММ) и строка заголовков", err))
+		return
+	}
+	t.sendMsg(reply)
+}
+
+func (t tgBot) downloadFile(fileId, path string) (err error) {
+	url, err := t.tgApi.GetFileDirectURL(fileId)
+	if err != nil {
+		return err
+	}
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("файл не скачан: %s", response.Status)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}(file)
+
+	_, err = io.Copy(file, response.Body)
+	return err
+}
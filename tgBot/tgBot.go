@@ -2,14 +2,20 @@ package tgBot
 
 import (
 	"callCenterReportMaker/controller"
+	"callCenterReportMaker/entity"
+	"callCenterReportMaker/payslip"
+	"callCenterReportMaker/period"
+	"callCenterReportMaker/renderer"
+	"callCenterReportMaker/scheduler"
+	"callCenterReportMaker/templates"
 	"errors"
 	"fmt"
 	"github.com/Syfaro/telegram-bot-api"
-	"github.com/jasonlvhit/gocron"
 	"io"
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,64 +23,163 @@ const (
 	msgLayout   = "```\n%s```"
 	parseMode   = "MarkdownV2"
 	adminChatId = 738984490
-	reportPath  = "data/report.xlsx"
+	reportPath  = "data/report"
+	payslipPath = "data/payslip_%d.pdf"
 )
 
 type tgBot struct {
-	token                                      string
-	chatId                                     int64
-	controller                                 controller.Controller
-	weekdayReportingTime, weekendReportingTime string
-	tgApi                                      *tgbotapi.BotAPI
-	updates                                    tgbotapi.UpdatesChannel
+	token         string
+	chatId        int64
+	controller    controller.Controller
+	scheduler     scheduler.Scheduler
+	reportFormats []string
+	templates     templates.Templates
+	payslips      payslip.Generator
+	operatorChats map[string]int64
+	session       *session
+	location      *time.Location
+	calendar      period.Calendar
+	tgApi         *tgbotapi.BotAPI
+	updates       tgbotapi.UpdatesChannel
+}
+
+type session struct {
+	mu          sync.Mutex
+	report      *entity.WeeklyReport
+	reportFiles []string
+	draft       *draft
+	imageChats  map[int64]bool
+}
+
+func (s *session) isImageChat(chatId int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.imageChats[chatId]
+}
+
+func (s *session) setImageChat(chatId int64, images bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.imageChats[chatId] = images
+}
+
+// setReportFiles remembers the files of the last rendered report, older files in data may have other formats
+func (s *session) setReportFiles(files []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reportFiles = files
+}
+
+func (s *session) getReportFiles() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reportFiles
 }
 
 type TelegramStatisticsBot interface {
 	SendPreformattedMessage(message string) error
-	StartDailyReportSending()
+	StartScheduler()
 	StartBot()
 }
 
-func New(controller controller.Controller, token string, chatId int64, weekdayReportingTime, weekendReportingTime string) TelegramStatisticsBot {
+func New(controller controller.Controller, token string, chatId int64, jobs scheduler.Scheduler,
+	reportFormats []string, templates templates.Templates, payslips payslip.Generator, operatorChats map[string]int64, imageChats []int64,
+	location *time.Location, calendar period.Calendar) TelegramStatisticsBot {
 	bot, _ := tgbotapi.NewBotAPI(token)
 
-	return tgBot{
-		token:                token,
-		controller:           controller,
-		chatId:               chatId,
-		weekdayReportingTime: weekdayReportingTime,
-		weekendReportingTime: weekendReportingTime,
-		tgApi:                bot,
+	botSession := &session{imageChats: make(map[int64]bool), draft: loadDraft()}
+	for _, imageChat := range imageChats {
+		botSession.imageChats[imageChat] = true
 	}
+
+	t := tgBot{
+		token:         token,
+		controller:    controller,
+		chatId:        chatId,
+		scheduler:     jobs,
+		reportFormats: reportFormats,
+		templates:     templates,
+		payslips:      payslips,
+		operatorChats: operatorChats,
+		session:       botSession,
+		location:      location,
+		calendar:      calendar,
+		tgApi:         bot,
+	}
+
+	jobs.Handle(scheduler.DailyStats, t.sendStatisticsJob)
+	jobs.Handle(scheduler.Anomalies, t.sendAnomaliesJob)
+	jobs.Handle(scheduler.WeeklyDraft, t.makePayrollDraft)
+	jobs.Handle(scheduler.MonthlyRollup, t.sendPreviousMonthJob)
+	return t
 }
 
 func (t tgBot) SendPreformattedMessage(message string) error {
-	msg := tgbotapi.NewMessage(t.chatId, fmt.Sprintf(msgLayout, message))
+	return t.sendPreformattedMessageTo(t.chatId, message)
+}
+
+func (t tgBot) sendPreformattedMessageTo(chatId int64, message string) error {
+	msg := tgbotapi.NewMessage(chatId, fmt.Sprintf(msgLayout, message))
 	msg.ParseMode = parseMode
 	_, err := t.tgApi.Send(msg)
 	return err
 }
 
-func (t tgBot) StartDailyReportSending() {
-	_ = gocron.Every(1).Monday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Tuesday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Wednesday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Thursday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Friday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Saturday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-	_ = gocron.Every(1).Sunday().At(t.weekdayReportingTime).Do(t.MakeWeeklyConversionStatisticsAndSend)
-
-	<-gocron.Start()
+func (t tgBot) StartScheduler() {
+	if err := t.scheduler.Start(); err != nil {
+		log.Println(err)
+		t.sendMsg(err.Error())
+	}
 }
 
 func (t tgBot) MakeWeeklyConversionStatisticsAndSend() error {
-	stats := t.controller.MakeWeeklyConversionStatistics()
-	err := t.SendPreformattedMessage(stats)
-	return err
+	err := t.sendWeeklyConversionStatistics(t.chatId)
+
+	warning, anomaliesErr := t.controller.CheckAnomalies()
+	if warning != "" {
+		t.sendMsg(warning)
+	}
+	return errors.Join(err, anomaliesErr)
+}
+
+func (t tgBot) sendWeeklyConversionStatistics(chatId int64) error {
+	if t.session.isImageChat(chatId) {
+		images, err := t.controller.MakeWeeklyConversionImages()
+		if err == nil {
+			for _, img := range images {
+				if _, err = t.tgApi.Send(tgbotapi.NewPhotoUpload(chatId, tgbotapi.FileBytes{Name: "statistics.png", Bytes: img})); err != nil {
+					return err
+				}
+			}
+			return t.sendPreformattedMessageTo(chatId, t.controller.MakeGradeAttainment())
+		}
+		log.Println(err)
+	}
+
+	return t.sendPreformattedMessageTo(chatId, t.controller.MakeWeeklyConversionStatistics())
+}
+
+func (t tgBot) setStatisticsView(args []string) {
+	if len(args) < 2 || (args[1] != "картинка" && args[1] != "текст") {
+		t.sendMsg("Укажите вид: \"Вид картинка\" или \"Вид текст\", можно добавить id чата")
+		return
+	}
+
+	chatId := t.chatId
+	if len(args) > 2 {
+		var err error
+		if chatId, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+			t.sendMsg(err.Error())
+			return
+		}
+	}
+
+	t.session.setImageChat(chatId, args[1] == "картинка")
+	t.sendMsg(fmt.Sprintf("Статистика для чата %d будет приходить в виде: %s", chatId, args[1]))
 }
 
 func (t tgBot) StartBot() {
-	go t.StartDailyReportSending()
+	t.StartScheduler()
 	t.processMessages()
 }
 
@@ -88,6 +193,13 @@ func (t tgBot) processMessages() {
 	}
 
 	for update := range t.updates {
+		if update.CallbackQuery != nil {
+			t.handleCallback(update.CallbackQuery)
+			continue
+		}
+		if update.Message == nil {
+			continue
+		}
 
 		chatId := update.Message.Chat.ID
 
@@ -97,6 +209,8 @@ func (t tgBot) processMessages() {
 				update.Message.From.FirstName,
 				update.Message.From.LastName,
 				update.Message.Text))
+		} else if update.Message.Document != nil {
+			t.importShifts(update.Message.Document)
 		} else {
 			selectCommand(t, update.Message.Text)
 
@@ -104,57 +218,186 @@ func (t tgBot) processMessages() {
 	}
 }
 
-func (t tgBot) makeReport() {
+func (t tgBot) askPeriod() (dateFrom, dateTo time.Time, err error) {
+	t.sendMsg("С какого числа? (ДД.ММ.ГГГГ)")
 
-	dateFrom, err := t.parseDateFromReader(t)
+	dateFrom, err = t.parseDateFromReader(t)
 	if err != nil {
-		t.sendMsg(err.Error())
-		return
+		return time.Time{}, time.Time{}, err
 	}
 
 	t.sendMsg("По какое число? (ДД.ММ.ГГГГ)")
 
-	dateTo, err := t.parseDateFromReader(t)
+	dateTo, err = t.parseDateFromReader(t)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return dateFrom, dateTo, nil
+}
+
+func (t tgBot) makeReport(args []string) {
+	dateFrom, dateTo, args, err := t.getPeriod(args)
 	if err != nil {
 		t.sendMsg(err.Error())
 		return
 	}
+	formats := t.reportFormats
+	if len(args) > 0 {
+		formats = args
+	}
 
 	t.sendMsg("Даты заданы. Считаем бонус")
 
-	report, err := t.controller.MakeReport(dateFrom, dateTo, t)
-	err = report.SaveAsXlsx(reportPath)
+	report, err := t.controller.MakeReport(dateFrom, dateTo, entity.ReportInputs{}, t)
 	if err != nil {
 		t.sendMsg(err.Error())
 		return
 	}
 
-	docConfig := tgbotapi.NewDocumentUpload(adminChatId, reportPath)
-	_, err = t.tgApi.Send(docConfig)
+	files, err := renderer.RenderAll(report, reportPath, formats, t.templates)
 	if err != nil {
 		t.sendMsg(err.Error())
 		return
 	}
 
+	t.session.setReportFiles(files)
+	t.sendFiles(files)
+	t.session.report = &report
+	t.sendMsg("Чтобы разослать операторам расчетные листы, отправьте \"Утвердить\"")
+}
+
+func (t tgBot) approveReport() {
+	if t.session.report == nil {
+		t.sendMsg("Нет отчета для утверждения, сначала сформируйте его командой \"Отчет\"")
+		return
+	}
+	report := *t.session.report
+	if err := t.controller.ApprovePayroll(report); err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+
+	var sent, skipped []string
+	for i, operatorReport := range report.OperatorReports {
+		chatId, ok := t.operatorChats[operatorReport.Name]
+		if !ok {
+			skipped = append(skipped, operatorReport.Name)
+			continue
+		}
+
+		path := fmt.Sprintf(payslipPath, i)
+		if err := t.payslips.Generate(report, operatorReport, path); err != nil {
+			t.sendMsg(err.Error())
+			return
+		}
+		if _, err := t.tgApi.Send(tgbotapi.NewDocumentUpload(chatId, path)); err != nil {
+			t.sendMsg(fmt.Sprintf("%s: %s", operatorReport.Name, err.Error()))
+			continue
+		}
+		sent = append(sent, operatorReport.Name)
+	}
+	t.session.report = nil
+
+	message := "Расчетные листы отправлены: " + strings.Join(sent, ", ")
+	if len(skipped) > 0 {
+		message += "\nНе указан чат: " + strings.Join(skipped, ", ")
+	}
+	t.sendMsg(message)
+}
+
+func (t tgBot) sendFiles(files []string) {
+	for _, file := range files {
+		docConfig := tgbotapi.NewDocumentUpload(adminChatId, file)
+		_, err := t.tgApi.Send(docConfig)
+		if err != nil {
+			t.sendMsg(err.Error())
+			return
+		}
+	}
+}
+
+func (t tgBot) makePeriodReport(args []string, makeReport func(dateFrom, dateTo time.Time) (string, error)) {
+	dateFrom, dateTo, _, err := t.getPeriod(args)
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+
+	report, err := makeReport(dateFrom, dateTo)
+	if err != nil {
+		t.sendMsg(err.Error())
+		return
+	}
+
+	err = t.SendPreformattedMessage(report)
+	if err != nil {
+		t.sendMsg(err.Error())
+	}
 }
 
 func selectCommand(t tgBot, usrTxt string) {
-	switch usrTxt {
+	args := strings.Fields(usrTxt)
+	if len(args) == 0 {
+		t.sendMsg("Неизвестная команда")
+		return
+	}
+
+	switch args[0] {
 	case "Статистика":
+		if len(args) > 1 {
+			t.sendPeriodStatistics(args[1:])
+			return
+		}
 		err := t.MakeWeeklyConversionStatisticsAndSend()
 		if err != nil {
 			t.sendMsg(err.Error())
 		}
+	case "Грейд":
+		err := t.SendPreformattedMessage(t.controller.MakeGradeAttainment())
+		if err != nil {
+			t.sendMsg(err.Error())
+		}
+	case "Вид":
+		t.setStatisticsView(args)
+
+	case "Аномалии":
+		warning, err := t.controller.CheckAnomalies()
+		if err != nil {
+			warning = err.Error()
+		} else if warning == "" {
+			warning = "Отклонений не найдено"
+		}
+		t.sendMsg(warning)
+
 	case "Отчет":
-		t.sendMsg("С какого числа? (ДД.ММ.ГГГГ)")
-		t.makeReport()
+		t.makeReport(args[1:])
+
+	case "Утвердить":
+		t.approveReport()
+
+	case "Клиенты":
+		t.makePeriodReport(args[1:], t.controller.MakeJourneyReport)
+
+	case "Реклама":
+		t.makePeriodReport(args[1:], t.controller.MakeMarketingReport)
+
+	case "Конверсия":
+		t.makePeriodReport(args[1:], t.controller.MakeLinkageReport)
+
+	case "Линии":
+		t.makePeriodReport(args[1:], t.controller.MakeLineMatchReport)
+
+	case "Задачи":
+		t.manageJobs(args)
 
 	case "Пришли":
-		docConfig := tgbotapi.NewDocumentUpload(adminChatId, reportPath)
-		_, err := t.tgApi.Send(docConfig)
-		if err != nil {
-			t.sendMsg(err.Error())
+		files := t.session.getReportFiles()
+		if len(files) == 0 {
+			t.sendMsg("Нет сформированного отчета, сначала сформируйте его командой \"Отчет\"")
+			return
 		}
+		t.sendFiles(files)
 
 	default:
 		t.sendMsg("Неизвестная команда")
@@ -210,11 +453,15 @@ func (t tgBot) parseDateFromReader(reader io.Reader) (time.Time, error) {
 		return time.Time{}, err
 	}
 
-	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), nil
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, t.location), nil
 }
 
 func (t tgBot) Read(b []byte) (n int, err error) {
 	message := <-t.updates
+	//buttons pressed while the bot waits for an answer are ignored
+	for message.Message == nil {
+		message = <-t.updates
+	}
 	bytesFromTg := []byte(message.Message.Text)
 	var count int
 	for count = 0; count < len(bytesFromTg); count++ {
@@ -0,0 +1,44 @@
+package tgBot
+
+import (
+	"io"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// message answers like the bot does, the whole text with io.EOF
+type message string
+
+func (m message) Read(b []byte) (int, error) {
+	return copy(b, m), io.EOF
+}
+
+func TestParseDateFromReader(t *testing.T) {
+	for _, name := range []string{"Europe/Moscow", "Asia/Vladivostok", "America/New_York"} {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot := tgBot{location: location}
+
+		t.Run(name, func(t *testing.T) {
+			got, err := bot.parseDateFromReader(message("10.03.2024"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := time.Date(2024, time.March, 10, 0, 0, 0, 0, location); !got.Equal(want) || got.Location() != location {
+				t.Errorf("parseDateFromReader = %s, want %s", got, want)
+			}
+		})
+	}
+
+	bot := tgBot{location: time.UTC}
+	for _, text := range []string{"10.03", "10-03-2024", "10.март.2024"} {
+		t.Run(text, func(t *testing.T) {
+			if _, err := bot.parseDateFromReader(message(text)); err == nil {
+				t.Errorf("parseDateFromReader(%q) accepted the wrong date", text)
+			}
+		})
+	}
+}